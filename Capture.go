@@ -3,10 +3,9 @@ package tlsaux
 import (
 	"bytes"
 	"crypto/tls"
-	"encoding/binary"
 	"io"
 	"net"
-	"strings"
+	"sort"
 	"sync"
 	"time"
 
@@ -24,47 +23,122 @@ type SecurityParameters struct {
 	MasterSecret      []byte
 	ClientRandom      []byte
 	ServerRandom      []byte
+	Secrets           map[nsskeylog.Label][]byte
 }
 
-type auxTLSPlaintextDecoder struct {
-	Done              bool
-	Buffer            bytes.Buffer
-	HandleClientHello func(*recordfmt.ClientHello)
-	HandleServerHello func(*recordfmt.ServerHello)
+func newSecurityParameters(ch *recordfmt.ClientHello, sh *recordfmt.ServerHello, pms, transcript []byte) (r *SecurityParameters, err error) {
+	version := int(sh.SelectedVersion())
+	suite := uint16(sh.CipherSuite)
+
+	// RFC 7627, the session hash covers every message up to ClientKeyExchange.
+	_, ems := sh.Extensions.Find(recordfmt.ExtensionExtendedMasterSecret)
+
+	var fn func(result, secret, label, seed []byte)
+	if fn, err = prf.New(version, suite); err == nil {
+		err = assert(!ems || len(transcript) > 0, ErrExtendedMasterSecret)
+	}
+
+	if err == nil {
+		var master []byte
+		if ems {
+			master = prf.ExtendedMasterSecret(fn, pms, prf.SessionHash(version, suite, transcript))
+		} else {
+			master = prf.MasterSecret(fn, pms, ch.Random[:], sh.Random[:])
+		}
+
+		r = &SecurityParameters{
+			PRF:               fn,
+			Version:           version,
+			CipherSuite:       suite,
+			CompressionMethod: uint8(sh.CompressionMethod),
+			MasterSecret:      master,
+			ClientRandom:      ch.Random[:],
+			ServerRandom:      sh.Random[:],
+			Secrets:           map[nsskeylog.Label][]byte{nsskeylog.ClientRandom: master},
+		}
+	}
+	return
 }
 
-func (s *auxTLSPlaintextDecoder) DecodeClientHello(r io.Reader) {
-	var v recordfmt.ClientHello
-	if err := v.Decode(r); err == nil {
-		s.HandleClientHello(&v)
-		s.Done = true
+// WriteKeyLog -
+func (s *SecurityParameters) WriteKeyLog(w *nsskeylog.Writer) error {
+	secrets := map[nsskeylog.Label][]byte{}
+	for k, v := range s.Secrets {
+		secrets[k] = v
+	}
+	if _, ok := secrets[nsskeylog.ClientRandom]; !ok && len(s.MasterSecret) > 0 {
+		secrets[nsskeylog.ClientRandom] = s.MasterSecret
 	}
+	return writeKeyLog(w, s.ClientRandom, secrets)
 }
 
-func (s *auxTLSPlaintextDecoder) DecodeServerHello(r io.Reader) {
-	var v recordfmt.ServerHello
-	if err := v.Decode(r); err == nil {
-		s.HandleServerHello(&v)
-		s.Done = true
+func writeKeyLog(w *nsskeylog.Writer, crand []byte, secrets map[nsskeylog.Label][]byte) (err error) {
+	labels := []nsskeylog.Label{}
+	for k := range secrets {
+		labels = append(labels, k)
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i] < labels[j] })
+
+	for i := 0; i < len(labels) && err == nil; i++ {
+		err = w.WriteLine(labels[i], crand, secrets[labels[i]])
 	}
+	return
 }
 
-func (s *auxTLSPlaintextDecoder) DecodeHandshake(r io.Reader) {
-	var v recordfmt.Handshake
-	if err := v.Decode(r); err == nil {
-		switch v.MsgType {
-		case recordfmt.TypeClientHello:
-			s.DecodeClientHello(bytes.NewReader(v.Body))
-		case recordfmt.TypeServerHello:
-			s.DecodeServerHello(bytes.NewReader(v.Body))
+type auxTLSPlaintextDecoder struct {
+	Done                   bool
+	Started                bool
+	Buffer                 bytes.Buffer
+	Handshakes             bytes.Buffer
+	HandleHandshake        func(*recordfmt.Handshake)
+	HandleAlert            func(*recordfmt.Alert)
+	HandleChangeCipherSpec func() bool
+}
+
+func (s *auxTLSPlaintextDecoder) DecodeHandshakes(v []byte) {
+	s.Handshakes.Write(v) // always success
+
+	// handshake messages may be coalesced into or fragmented across records.
+	for s.Handshakes.Len() >= 4 {
+		aux := s.Handshakes.Bytes()
+		length := (int(aux[1]) << 16) + (int(aux[2]) << 8) + int(aux[3])
+		if length > recordfmt.DefaultDecodeOptions.MaxHandshakeLength {
+			// refuse to buffer what a hostile peer claims.
+			s.Done = true
+			break
+		}
+		if length+4 > len(aux) {
+			break
+		}
+
+		var v recordfmt.Handshake
+		if err := v.Decode(&s.Handshakes); err == nil {
+			s.HandleHandshake(&v)
+		}
+	}
+}
+
+func (s *auxTLSPlaintextDecoder) DecodeAlerts(v []byte) {
+	for r := bytes.NewReader(v); r.Len() >= 2; {
+		var v recordfmt.Alert
+		if err := v.Decode(r); err == nil {
+			s.HandleAlert(&v)
 		}
 	}
 }
 
 func (s *auxTLSPlaintextDecoder) DecodeTLSPlaintext(r io.Reader) {
 	var v recordfmt.TLSPlaintext
-	if err := v.Decode(r); err == nil && v.Type == recordfmt.TypeHandshake {
-		s.DecodeHandshake(bytes.NewReader(v.Fragment))
+	if err := v.Decode(r); err == nil {
+		switch v.Type {
+		case recordfmt.TypeHandshake:
+			s.DecodeHandshakes(v.Fragment)
+		case recordfmt.TypeAlert:
+			s.DecodeAlerts(v.Fragment)
+		case recordfmt.TypeChangeCipherSpec:
+			// records after this point are encrypted in this direction.
+			s.Done = s.HandleChangeCipherSpec()
+		}
 	}
 }
 
@@ -72,16 +146,28 @@ func (s *auxTLSPlaintextDecoder) Push(v []byte) {
 	if !s.Done {
 		s.Buffer.Write(v) // always success
 
-		aux := struct {
-			ContentType     uint8
-			ProtocolVersion uint16
-			FragmentLength  uint16
-		}{}
+		for !s.Done {
+			if s.Started && recordfmt.IsSSLv2Record(s.Buffer.Bytes()) {
+				// SSLv2 framing is only valid for the first record, past it
+				// the stream cannot be followed.
+				s.Done = true
+				break
+			}
 
-		for binary.Read(bytes.NewReader(s.Buffer.Bytes()), binary.BigEndian, &aux) == nil {
-			if int(aux.FragmentLength)+5 <= s.Buffer.Len() {
-				s.DecodeTLSPlaintext(&s.Buffer)
+			// SSLv2 compatible hellos are framed differently, let recordfmt tell.
+			n, ok := recordfmt.RecordLength(s.Buffer.Bytes())
+			if !ok || n > s.Buffer.Len() {
+				break
 			}
+
+			// a record that fails to decode is dropped whole, never re-read as headers.
+			s.DecodeTLSPlaintext(bytes.NewReader(s.Buffer.Next(n)))
+			s.Started = true
+		}
+
+		if s.Done {
+			s.Buffer.Reset()
+			s.Handshakes.Reset()
 		}
 	}
 }
@@ -139,79 +225,33 @@ type auxWriter struct {
 	Buffer          []byte
 }
 
-func (s *auxWriter) ReadLines() []string {
-	ret := []string{}
-	for {
-		// to avoid blocking, not use standard scanner.
-		idx := bytes.IndexByte(s.Buffer, '\n')
-		if idx < 0 {
-			break
-		}
-		ret = append(ret, string(s.Buffer[:idx]))
-		s.Buffer = s.Buffer[idx+1:]
-	}
-	return ret
-}
-
 func (s *auxWriter) Write(p []byte) (n int, err error) {
 	s.Locker.Lock()
 	n, s.Buffer = len(p), append(s.Buffer, p...)
 
-	for _, line := range s.ReadLines() {
-		line = strings.TrimSpace(line)
-		if !strings.HasPrefix(line, "#") {
-			if label, crand, secret, err := nsskeylog.Parse(line); err == nil && label == nsskeylog.ClientRandom {
-				s.HandleNSSKeyLog(label, crand, secret)
-			}
+	// to avoid blocking on a partial line, only hand over complete lines.
+	if idx := bytes.LastIndexByte(s.Buffer, '\n'); idx >= 0 {
+		r := nsskeylog.NewReader(bytes.NewReader(s.Buffer[:idx+1]), &nsskeylog.ReaderOptions{SkipInvalid: true})
+		for v, err := r.Next(); err == nil; v, err = r.Next() {
+			s.HandleNSSKeyLog(v.Label, v.ClientRandom, v.Secret)
 		}
+		s.Buffer = append([]byte{}, s.Buffer[idx+1:]...)
 	}
 
 	s.Locker.Unlock()
 	return
 }
 
-type rawCapture struct {
-	Locker             sync.Mutex
-	ClientHello        *recordfmt.ClientHello
-	ServerHello        *recordfmt.ServerHello
-	SecurityParameters *SecurityParameters
-}
-
-func (s *rawCapture) HandleClientHello(v *recordfmt.ClientHello) {
-	s.Locker.Lock()
-	s.ClientHello = v
-	s.ServerHello = nil
-	s.SecurityParameters = nil
-	s.Locker.Unlock()
-}
-
-func (s *rawCapture) HandleServerHello(v *recordfmt.ServerHello) {
-	s.Locker.Lock()
-	s.ServerHello = v
-	s.Locker.Unlock()
-}
-
-func (s *rawCapture) HandleNSSKeyLog(label nsskeylog.Label, crand, secret []byte) {
-	s.Locker.Lock()
-	if bytes.Compare(s.ClientHello.Random, crand) == 0 {
-		s.SecurityParameters = &SecurityParameters{
-			PRF:               prf.New(int(s.ServerHello.ServerVersion), uint16(s.ServerHello.CipherSuite)),
-			Version:           int(s.ServerHello.ServerVersion),
-			CipherSuite:       uint16(s.ServerHello.CipherSuite),
-			CompressionMethod: uint8(s.ServerHello.CompressionMethod),
-			MasterSecret:      secret[:],
-			ClientRandom:      s.ClientHello.Random[:],
-			ServerRandom:      s.ServerHello.Random[:],
-		}
+func newAuxDecoder(session *Session, local bool) *auxTLSPlaintextDecoder {
+	return &auxTLSPlaintextDecoder{
+		HandleHandshake: func(v *recordfmt.Handshake) {
+			session.handleHandshake(local, v)
+		},
+		HandleAlert: func(v *recordfmt.Alert) {
+			session.handleAlert(local, v)
+		},
+		HandleChangeCipherSpec: session.handleChangeCipherSpec,
 	}
-	s.Locker.Unlock()
-}
-
-func (s *rawCapture) Retrieve() (r *SecurityParameters) {
-	s.Locker.Lock()
-	r = s.SecurityParameters
-	s.Locker.Unlock()
-	return
 }
 
 func mergeWriters(w ...io.Writer) io.Writer {
@@ -224,26 +264,44 @@ func mergeWriters(w ...io.Writer) io.Writer {
 	return io.MultiWriter(m...)
 }
 
-// Capture -
-func Capture(conn net.Conn, config *tls.Config, fn func(net.Conn, *tls.Config) *tls.Conn) (*tls.Conn, func() *SecurityParameters) {
-	capture := &rawCapture{}
+// CaptureSession -
+func CaptureSession(conn net.Conn, config *tls.Config, fn func(net.Conn, *tls.Config) *tls.Conn) (*tls.Conn, *Session) {
+	session := &Session{}
 
 	conn = &auxConn{
-		Conn: conn,
-		ReaderDecoder: &auxTLSPlaintextDecoder{
-			HandleClientHello: capture.HandleClientHello,
-			HandleServerHello: capture.HandleServerHello,
-		},
-		WriterDecoder: &auxTLSPlaintextDecoder{
-			HandleClientHello: capture.HandleClientHello,
-			HandleServerHello: capture.HandleServerHello,
-		},
+		Conn:          conn,
+		ReaderDecoder: newAuxDecoder(session, false),
+		WriterDecoder: newAuxDecoder(session, true),
 	}
 
+	return fn(conn, tapKeyLog(config, session)), session
+}
+
+func tapKeyLog(config *tls.Config, session *Session) *tls.Config {
 	config = config.Clone()
 	config.KeyLogWriter = mergeWriters(
 		config.KeyLogWriter,
-		&auxWriter{HandleNSSKeyLog: capture.HandleNSSKeyLog})
+		&auxWriter{HandleNSSKeyLog: session.handleNSSKeyLog})
+	return config
+}
 
-	return fn(conn, config), capture.Retrieve
+// CaptureServer -
+func CaptureServer(conn net.Conn, config *tls.Config, fn func(*Session) (*tls.Config, error)) (tlsconn *tls.Conn, session *Session) {
+	config = config.Clone()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (r *tls.Config, err error) {
+		// the ClientHello record has been read through the capture conn by now.
+		if r, err = fn(session); err == nil && r != nil {
+			r = tapKeyLog(r, session)
+		}
+		return
+	}
+
+	tlsconn, session = CaptureSession(conn, config, tls.Server)
+	return
+}
+
+// Capture -
+func Capture(conn net.Conn, config *tls.Config, fn func(net.Conn, *tls.Config) *tls.Conn) (*tls.Conn, func() *SecurityParameters) {
+	tlsconn, session := CaptureSession(conn, config, fn)
+	return tlsconn, session.SecurityParameters
 }
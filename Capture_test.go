@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"github.com/maxbet1507/tlsaux"
+	"github.com/maxbet1507/tlsaux/fingerprint"
 	"github.com/maxbet1507/tlsaux/testcert"
+	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -39,6 +41,7 @@ func TestCapture(t *testing.T) {
 	}
 	clconfig := &tls.Config{
 		InsecureSkipVerify: true,
+		MaxVersion:         tls.VersionTLS12,
 	}
 
 	clconn, svconn, err := netpipe()
@@ -97,3 +100,96 @@ func TestCapture(t *testing.T) {
 		t.Fatal(e1, e2)
 	}
 }
+
+func TestCapture_TLS13(t *testing.T) {
+	cert, pkey, _ := testcert.SelfSigned(1024, 10*time.Second)
+	pair, _ := tls.X509KeyPair(cert, pkey)
+
+	clconn, svconn, err := netpipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clconn.Close()
+	defer svconn.Close()
+
+	client, clcapture := tlsaux.Capture(clconn, &tls.Config{InsecureSkipVerify: true}, tls.Client)
+	server, svcapture := tlsaux.Capture(svconn, &tls.Config{Certificates: []tls.Certificate{pair}}, tls.Server)
+
+	eg := errgroup.Group{}
+	eg.Go(client.Handshake)
+	eg.Go(server.Handshake)
+
+	if err := eg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	// no master secret, no parameters, as it has always been.
+	if clparams, svparams := clcapture(), svcapture(); clparams != nil || svparams != nil {
+		t.Fatal(clparams, svparams)
+	}
+}
+
+func TestCaptureServer(t *testing.T) {
+	cert, pkey, _ := testcert.SelfSigned(1024, 10*time.Second)
+	pair, _ := tls.X509KeyPair(cert, pkey)
+
+	clconn, svconn, err := netpipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clconn.Close()
+	defer svconn.Close()
+
+	var raw []byte
+	var ja4 *fingerprint.Variants
+	server, svsession := tlsaux.CaptureServer(svconn, &tls.Config{}, func(v *tlsaux.Session) (*tls.Config, error) {
+		raw, ja4 = v.RawClientHello(), v.JA4()
+		if v.ClientHello().ServerName() != "localhost" {
+			return nil, errors.New("unknown server name")
+		}
+		return &tls.Config{Certificates: []tls.Certificate{pair}}, nil
+	})
+	client, clsession := tlsaux.CaptureSession(clconn, &tls.Config{InsecureSkipVerify: true, ServerName: "localhost"}, tls.Client)
+
+	eg := errgroup.Group{}
+	eg.Go(client.Handshake)
+	eg.Go(server.Handshake)
+
+	if err := eg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(raw) == 0 || raw[0] != 0x01 || !bytes.Equal(raw, clsession.RawClientHello()) {
+		t.Fatal(raw)
+	}
+	if ja4 == nil || *ja4 != *clsession.JA4() {
+		t.Fatal(ja4)
+	}
+
+	// secrets are still tapped with the config picked by the callback.
+	if len(svsession.Secrets()) == 0 {
+		t.Fatal(svsession)
+	}
+}
+
+func TestCaptureServer_Reject(t *testing.T) {
+	clconn, svconn, err := netpipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clconn.Close()
+	defer svconn.Close()
+
+	server, _ := tlsaux.CaptureServer(svconn, &tls.Config{}, func(v *tlsaux.Session) (*tls.Config, error) {
+		return nil, errors.New("rejected")
+	})
+	client := tls.Client(clconn, &tls.Config{InsecureSkipVerify: true, ServerName: "example.com"})
+
+	eg := errgroup.Group{}
+	eg.Go(client.Handshake)
+	eg.Go(server.Handshake)
+
+	if err := eg.Wait(); err == nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,101 @@
+package tlsaux
+
+import (
+	"crypto/tls"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+)
+
+var (
+	implementedCurves = map[tls.CurveID]bool{
+		tls.X25519:    true,
+		tls.CurveP256: true,
+		tls.CurveP384: true,
+		tls.CurveP521: true,
+	}
+)
+
+func implementedCipherSuites() map[uint16]bool {
+	r := map[uint16]bool{}
+	for _, v := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		// TLS 1.3 suites are not configurable.
+		for _, w := range v.SupportedVersions {
+			r[v.ID] = r[v.ID] || w < tls.VersionTLS13
+		}
+	}
+	return r
+}
+
+func supportedVersions(ch *recordfmt.ClientHello) (r []uint16) {
+	if list := ch.SupportedVersions(); list != nil {
+		for _, v := range list {
+			r = append(r, uint16(v))
+		}
+		return
+	}
+
+	// without the extension, everything up to the legacy version is offered.
+	for v := uint16(ch.ClientVersion); v >= tls.VersionTLS10 && v <= tls.VersionTLS12; v-- {
+		r = append(r, v)
+	}
+	return
+}
+
+// ClientHelloInfo -
+func ClientHelloInfo(ch *recordfmt.ClientHello) *tls.ClientHelloInfo {
+	v := &tls.ClientHelloInfo{
+		ServerName:        ch.ServerName(),
+		SupportedPoints:   ch.ECPointFormats(),
+		SupportedProtos:   ch.ALPN(),
+		SupportedVersions: supportedVersions(ch),
+	}
+	for _, w := range ch.CipherSuites {
+		v.CipherSuites = append(v.CipherSuites, uint16(w))
+	}
+	for _, w := range ch.SupportedGroups() {
+		v.SupportedCurves = append(v.SupportedCurves, tls.CurveID(w))
+	}
+	for _, w := range ch.SignatureAlgorithms() {
+		v.SignatureSchemes = append(v.SignatureSchemes, tls.SignatureScheme(w))
+	}
+	return v
+}
+
+// ClientConfig -
+func ClientConfig(ch *recordfmt.ClientHello) *tls.Config {
+	ch = ch.Normalize()
+
+	// best effort, anything crypto/tls does not implement is dropped.
+	v := &tls.Config{
+		ServerName: ch.ServerName(),
+		NextProtos: ch.ALPN(),
+	}
+
+	suites := implementedCipherSuites()
+	for _, w := range ch.CipherSuites {
+		if suites[uint16(w)] {
+			v.CipherSuites = append(v.CipherSuites, uint16(w))
+		}
+	}
+	for _, w := range ch.SupportedGroups() {
+		if implementedCurves[tls.CurveID(w)] {
+			v.CurvePreferences = append(v.CurvePreferences, tls.CurveID(w))
+		}
+	}
+	for _, w := range supportedVersions(ch) {
+		if w < tls.VersionTLS10 || w > tls.VersionTLS13 {
+			continue
+		}
+		if v.MinVersion == 0 || w < v.MinVersion {
+			v.MinVersion = w
+		}
+		if w > v.MaxVersion {
+			v.MaxVersion = w
+		}
+	}
+
+	_, tickets := ch.Extensions.Find(recordfmt.ExtensionSessionTicket)
+	v.SessionTicketsDisabled = !tickets
+
+	return v
+}
@@ -0,0 +1,88 @@
+package tlsaux_test
+
+import (
+	"crypto/tls"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/maxbet1507/tlsaux"
+	"github.com/maxbet1507/tlsaux/testcert"
+)
+
+func TestClientHelloInfo(t *testing.T) {
+	cert, pkey, _ := testcert.SelfSigned(1024, 10*time.Second)
+	pair, _ := tls.X509KeyPair(cert, pkey)
+
+	var observed *tls.ClientHelloInfo
+	svconfig := &tls.Config{
+		GetConfigForClient: func(v *tls.ClientHelloInfo) (*tls.Config, error) {
+			observed = v
+			return nil, nil
+		},
+		Certificates: []tls.Certificate{pair},
+		NextProtos:   []string{"h2"},
+	}
+	clconfig := &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         "localhost",
+		CurvePreferences:   []tls.CurveID{tls.CurveP384, tls.X25519},
+		NextProtos:         []string{"h2", "http/1.1"},
+	}
+
+	client, server, clsession, _ := handshake(t, clconfig, svconfig)
+	defer client.Close()
+	defer server.Close()
+
+	info := tlsaux.ClientHelloInfo(clsession.ClientHello())
+	if info.ServerName != observed.ServerName || !reflect.DeepEqual(info.SupportedProtos, observed.SupportedProtos) {
+		t.Fatal(info)
+	}
+	if !reflect.DeepEqual(info.CipherSuites, observed.CipherSuites) || !reflect.DeepEqual(info.SupportedCurves, observed.SupportedCurves) {
+		t.Fatal(info)
+	}
+	if !reflect.DeepEqual(info.SupportedVersions, observed.SupportedVersions) || !reflect.DeepEqual(info.SignatureSchemes, observed.SignatureSchemes) {
+		t.Fatal(info)
+	}
+	if !reflect.DeepEqual(info.SupportedPoints, observed.SupportedPoints) {
+		t.Fatal(info)
+	}
+}
+
+func TestClientConfig(t *testing.T) {
+	cert, pkey, _ := testcert.SelfSigned(1024, 10*time.Second)
+	pair, _ := tls.X509KeyPair(cert, pkey)
+
+	svconfig := &tls.Config{Certificates: []tls.Certificate{pair}}
+	clconfig := &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         "localhost",
+		MaxVersion:         tls.VersionTLS12,
+		CipherSuites:       []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+		CurvePreferences:   []tls.CurveID{tls.CurveP384, tls.X25519},
+		NextProtos:         []string{"http/1.1"},
+	}
+
+	client, server, clsession, _ := handshake(t, clconfig, svconfig)
+	client.Close()
+	server.Close()
+
+	// replay the observed offer and compare both hellos.
+	replayed := tlsaux.ClientConfig(clsession.ClientHello())
+	replayed.InsecureSkipVerify = true
+
+	client, server, resession, _ := handshake(t, replayed, svconfig)
+	defer client.Close()
+	defer server.Close()
+
+	original, replay := tlsaux.ClientHelloInfo(clsession.ClientHello()), tlsaux.ClientHelloInfo(resession.ClientHello())
+	if original.ServerName != replay.ServerName || !reflect.DeepEqual(original.SupportedProtos, replay.SupportedProtos) {
+		t.Fatal(replay)
+	}
+	if !reflect.DeepEqual(original.CipherSuites, replay.CipherSuites) || !reflect.DeepEqual(original.SupportedCurves, replay.SupportedCurves) {
+		t.Fatal(replay)
+	}
+	if !reflect.DeepEqual(original.SupportedVersions, replay.SupportedVersions) {
+		t.Fatal(replay)
+	}
+}
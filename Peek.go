@@ -0,0 +1,89 @@
+package tlsaux
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+	"github.com/pkg/errors"
+)
+
+// -
+var (
+	ErrNotClientHello = fmt.Errorf("Not ClientHello")
+)
+
+type peekedConn struct {
+	net.Conn
+	Reader io.Reader
+}
+
+func (s *peekedConn) Read(p []byte) (int, error) {
+	return s.Reader.Read(p)
+}
+
+func peekRecord(r io.Reader, first bool) (v recordfmt.TLSPlaintext, err error) {
+	header := make([]byte, 5)
+
+	// reject anything else before waiting on a length that was never sent.
+	if _, err = io.ReadFull(r, header[:1]); err == nil {
+		err = assert(first && recordfmt.IsSSLv2Record(header) || header[0] == byte(recordfmt.TypeHandshake), ErrNotClientHello)
+	}
+
+	n := 5
+	if recordfmt.IsSSLv2Record(header) {
+		n = 2
+	}
+	if err == nil {
+		_, err = io.ReadFull(r, header[1:n])
+	}
+
+	if err == nil {
+		length, _ := recordfmt.RecordLength(header[:n])
+		record := append(header[:n], make([]byte, length-n)...)
+		if _, err = io.ReadFull(r, record[n:]); err == nil {
+			err = recordfmt.Unmarshal(record, &v, nil)
+		}
+	}
+	return
+}
+
+// PeekClientHello -
+func PeekClientHello(conn net.Conn) (hello *recordfmt.ClientHello, replay net.Conn, err error) {
+	var consumed, handshakes bytes.Buffer
+	r := io.TeeReader(conn, &consumed)
+
+	// the hello may be fragmented across records, and records across segments.
+	for hello == nil && err == nil {
+		var record recordfmt.TLSPlaintext
+		if record, err = peekRecord(r, consumed.Len() == 0); err != nil {
+			break
+		}
+		handshakes.Write(record.Fragment) // always success
+
+		aux := handshakes.Bytes()
+		if len(aux) < 4 {
+			continue
+		}
+		if err = assert(recordfmt.HandshakeType(aux[0]) == recordfmt.TypeClientHello, ErrNotClientHello); err != nil {
+			break
+		}
+
+		length := (int(aux[1]) << 16) + (int(aux[2]) << 8) + int(aux[3])
+		if err = assert(length <= recordfmt.DefaultDecodeOptions.MaxHandshakeLength, recordfmt.ErrTooLarge); err != nil {
+			break
+		}
+		if length+4 <= len(aux) {
+			var v recordfmt.ClientHello
+			if err = recordfmt.Unmarshal(aux[4:length+4], &v, nil); err == nil {
+				hello = &v
+			}
+		}
+	}
+
+	err = errors.Wrap(err, "PeekClientHello")
+	replay = &peekedConn{Conn: conn, Reader: io.MultiReader(&consumed, conn)}
+	return
+}
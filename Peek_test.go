@@ -0,0 +1,124 @@
+package tlsaux_test
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/maxbet1507/tlsaux"
+	"github.com/maxbet1507/tlsaux/testcert"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+type segmentedConn struct {
+	net.Conn
+}
+
+func (s *segmentedConn) Write(p []byte) (n int, err error) {
+	// one byte per segment, the worst case for a peeking reader.
+	for i := 0; i < len(p) && err == nil; i++ {
+		var m int
+		m, err = s.Conn.Write(p[i : i+1])
+		n += m
+	}
+	return
+}
+
+func TestPeekClientHello(t *testing.T) {
+	cert, pkey, _ := testcert.SelfSigned(1024, 10*time.Second)
+	pair, _ := tls.X509KeyPair(cert, pkey)
+
+	clconn, svconn := net.Pipe()
+	defer clconn.Close()
+	defer svconn.Close()
+
+	client := tls.Client(&segmentedConn{clconn}, &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         "backend.example.com",
+		NextProtos:         []string{"h2"},
+	})
+
+	eg := errgroup.Group{}
+	eg.Go(client.Handshake)
+	eg.Go(func() error {
+		hello, replay, err := tlsaux.PeekClientHello(svconn)
+		if err != nil {
+			return err
+		}
+		if hello.ServerName() != "backend.example.com" {
+			return errors.New(hello.ServerName())
+		}
+		if alpn := hello.ALPN(); len(alpn) != 1 || alpn[0] != "h2" {
+			return errors.New("unexpected ALPN")
+		}
+
+		// the backend sees the connection from its very first byte.
+		return tls.Server(replay, &tls.Config{Certificates: []tls.Certificate{pair}}).Handshake()
+	})
+
+	if err := eg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPeekClientHello_NotClientHello(t *testing.T) {
+	clconn, svconn := net.Pipe()
+	defer clconn.Close()
+	defer svconn.Close()
+
+	go clconn.Write([]byte("GET / HTTP/1.1\r\n\r\n"))
+
+	_, replay, err := tlsaux.PeekClientHello(svconn)
+	if errors.Cause(err) != tlsaux.ErrNotClientHello {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(replay, buf); err != nil || string(buf) != "GET /" {
+		t.Fatal(err, string(buf))
+	}
+}
+
+func TestPeekClientHello_SSLv2NotFirst(t *testing.T) {
+	clconn, svconn := net.Pipe()
+	defer clconn.Close()
+	defer svconn.Close()
+
+	// a partial handshake record, then an SSLv2 hello that must not be taken.
+	go clconn.Write([]byte{
+		0x16, 0x03, 0x01, 0x00, 0x02, 0x01, 0x00,
+		0x80, 0x22, 0x01, 0x03, 0x01, 0x00, 0x09, 0x00, 0x00, 0x00, 0x10,
+		0x07, 0x00, 0xc0, 0x00, 0x00, 0x2f, 0x00, 0x00, 0x0a,
+		0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17,
+		0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+	})
+
+	_, _, err := tlsaux.PeekClientHello(svconn)
+	if errors.Cause(err) != tlsaux.ErrNotClientHello {
+		t.Fatal(err)
+	}
+}
+
+func TestPeekClientHello_TLS10(t *testing.T) {
+	clconn, svconn := net.Pipe()
+	defer clconn.Close()
+
+	client := tls.Client(clconn, &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         "legacy.example.com",
+		MinVersion:         tls.VersionTLS10,
+		MaxVersion:         tls.VersionTLS10,
+	})
+	go client.Handshake()
+
+	hello, replay, err := tlsaux.PeekClientHello(svconn)
+	defer replay.Close()
+
+	// legacy clients are routed by SNI all the same.
+	if err != nil || hello.ClientVersion != tls.VersionTLS10 || hello.ServerName() != "legacy.example.com" {
+		t.Fatal(hello, err)
+	}
+}
@@ -0,0 +1,73 @@
+package tlsaux
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+	"github.com/pkg/errors"
+)
+
+// -
+var (
+	ErrIncompleteHandshake  = fmt.Errorf("Incomplete Handshake")
+	ErrNotRSAKeyExchange    = fmt.Errorf("Not RSA Key Exchange")
+	ErrExtendedMasterSecret = fmt.Errorf("Extended Master Secret")
+	ErrNoPrivateKey         = fmt.Errorf("No Private Key")
+	ErrPreMasterSecret      = fmt.Errorf("Pre-Master Secret")
+)
+
+// KeyRing -
+type KeyRing []*rsa.PrivateKey
+
+// Select -
+func (s KeyRing) Select(cert *x509.Certificate) *rsa.PrivateKey {
+	if pub, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+		for _, v := range s {
+			if v.PublicKey.Equal(pub) {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// DecryptRSA -
+func DecryptRSA(key *rsa.PrivateKey, ch *recordfmt.ClientHello, sh *recordfmt.ServerHello, cke *recordfmt.ClientKeyExchange, transcript []byte) (r *SecurityParameters, err error) {
+	var pms []byte
+	if err = assert(sh.CipherSuite.KeyExchange() == recordfmt.KeyExchangeRSA, ErrNotRSAKeyExchange); err == nil {
+		// a wrong key fails here or, rarely, with a bogus length below.
+		if pms, err = rsa.DecryptPKCS1v15(nil, key, cke.EncryptedPreMasterSecret); err == nil {
+			// the client repeats its hello version, a mismatch means rollback or the wrong key.
+			err = assert(len(pms) == 48 && recordfmt.ProtocolVersion(binary.BigEndian.Uint16(pms)) == ch.ClientVersion, ErrPreMasterSecret)
+		}
+	}
+
+	if err == nil {
+		r, err = newSecurityParameters(ch, sh, pms, transcript)
+	}
+	err = errors.Wrap(err, "DecryptRSA")
+	return
+}
+
+// DecryptRSA -
+func (s *Session) DecryptRSA(keys KeyRing) (r *SecurityParameters, err error) {
+	var key *rsa.PrivateKey
+
+	s.locker.Lock()
+	ch, sh, cke, transcript := s.clientHello, s.serverHello, s.clientKeyExchange, s.completeTranscript()
+	if err = assert(ch != nil && sh != nil && cke != nil && len(s.serverCertificates) > 0, ErrIncompleteHandshake); err == nil {
+		// the leaf certificate tells which of our servers answered.
+		key = keys.Select(s.serverCertificates[0])
+		err = assert(key != nil, ErrNoPrivateKey)
+	}
+	s.locker.Unlock()
+
+	if err == nil {
+		return DecryptRSA(key, ch, sh, cke, transcript)
+	}
+	err = errors.Wrap(err, "DecryptRSA")
+	return
+}
@@ -0,0 +1,211 @@
+package tlsaux_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/maxbet1507/tlsaux"
+	"github.com/maxbet1507/tlsaux/nsskeylog"
+	"github.com/maxbet1507/tlsaux/prf"
+	"github.com/maxbet1507/tlsaux/recordfmt"
+	"github.com/maxbet1507/tlsaux/testcert"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+func rsaKeyPair(t *testing.T) (tls.Certificate, *rsa.PrivateKey, *x509.Certificate) {
+	cert, pkey, _ := testcert.SelfSigned(1024, 10*time.Second)
+	pair, err := tls.X509KeyPair(cert, pkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pair, pair.PrivateKey.(*rsa.PrivateKey), leaf
+}
+
+func TestKeyRing_Select(t *testing.T) {
+	_, key1, leaf1 := rsaKeyPair(t)
+	_, key2, leaf2 := rsaKeyPair(t)
+
+	keys := tlsaux.KeyRing{key1}
+	if v := keys.Select(leaf1); v != key1 {
+		t.Fatal(v)
+	}
+	if v := keys.Select(leaf2); v != nil {
+		t.Fatal(v)
+	}
+
+	keys = append(keys, key2)
+	if v := keys.Select(leaf2); v != key2 {
+		t.Fatal(v)
+	}
+}
+
+func TestDecryptRSA(t *testing.T) {
+	_, key, _ := rsaKeyPair(t)
+	_, other, _ := rsaKeyPair(t)
+
+	pms := append([]byte{0x03, 0x03}, bytes.Repeat([]byte{0x42}, 46)...)
+	epms, err := rsa.EncryptPKCS1v15(rand.Reader, &key.PublicKey, pms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := &recordfmt.ClientHello{ClientVersion: tls.VersionTLS12}
+	sh := &recordfmt.ServerHello{ServerVersion: tls.VersionTLS12, CipherSuite: 0x009c}
+	copy(ch.Random[:], bytes.Repeat([]byte{0x01}, 32))
+	copy(sh.Random[:], bytes.Repeat([]byte{0x02}, 32))
+	cke := &recordfmt.ClientKeyExchange{EncryptedPreMasterSecret: epms}
+
+	params, err := tlsaux.DecryptRSA(key, ch, sh, cke, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chk := make([]byte, 48)
+	fn, _ := prf.New(tls.VersionTLS12, 0x009c)
+	fn(chk, pms, []byte("master secret"), append(ch.Random[:], sh.Random[:]...))
+	if !bytes.Equal(params.MasterSecret, chk) || !bytes.Equal(params.Secrets[nsskeylog.ClientRandom], chk) {
+		t.Fatal(params)
+	}
+	if params.Version != tls.VersionTLS12 || params.CipherSuite != 0x009c || params.PRF == nil {
+		t.Fatal(params)
+	}
+
+	if _, err := tlsaux.DecryptRSA(other, ch, sh, cke, nil); err == nil {
+		t.Fatal(err)
+	}
+
+	// a pre-master secret that does not repeat the hello version is refused.
+	rollback := *ch
+	rollback.ClientVersion = tls.VersionTLS11
+	if _, err := tlsaux.DecryptRSA(key, &rollback, sh, cke, nil); errors.Cause(err) != tlsaux.ErrPreMasterSecret {
+		t.Fatal(err)
+	}
+
+	ecdhe := *sh
+	ecdhe.CipherSuite = 0xc02f
+	if _, err := tlsaux.DecryptRSA(key, ch, &ecdhe, cke, nil); errors.Cause(err) != tlsaux.ErrNotRSAKeyExchange {
+		t.Fatal(err)
+	}
+
+	// crypto/tls never heard of this one, the capture is still ours.
+	legacy := *sh
+	legacy.CipherSuite = 0x003d // TLS_RSA_WITH_AES_256_CBC_SHA256
+	if _, err := tlsaux.DecryptRSA(key, ch, &legacy, cke, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ems := *sh
+	ems.Extensions = recordfmt.HelloExtensions{{ExtensionType: recordfmt.ExtensionExtendedMasterSecret}}
+	if _, err := tlsaux.DecryptRSA(key, ch, &ems, cke, nil); errors.Cause(err) != tlsaux.ErrExtendedMasterSecret {
+		t.Fatal(err)
+	}
+
+	transcript := []byte("TRANSCRIPT")
+	params, err = tlsaux.DecryptRSA(key, ch, &ems, cke, transcript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chk := prf.ExtendedMasterSecret(fn, pms, prf.SessionHash(tls.VersionTLS12, 0x009c, transcript)); !bytes.Equal(params.MasterSecret, chk) {
+		t.Fatal(params)
+	}
+}
+
+func TestSession_DecryptRSA(t *testing.T) {
+	pair, key, _ := rsaKeyPair(t)
+	_, other, _ := rsaKeyPair(t)
+
+	var keylog bytes.Buffer
+	suites := []uint16{tls.TLS_RSA_WITH_AES_128_GCM_SHA256}
+	svconfig := &tls.Config{Certificates: []tls.Certificate{pair}, CipherSuites: suites, MaxVersion: tls.VersionTLS12}
+	clconfig := &tls.Config{InsecureSkipVerify: true, CipherSuites: suites, KeyLogWriter: &keylog}
+
+	client, server, clsession, svsession := handshake(t, clconfig, svconfig)
+	defer client.Close()
+	defer server.Close()
+
+	_, _, master, err := nsskeylog.Parse(keylog.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, session := range []*tlsaux.Session{clsession, svsession} {
+		if v := session.ClientKeyExchange(); v == nil || len(v.EncryptedPreMasterSecret) != 128 {
+			t.Fatal(v)
+		}
+
+		if _, err := session.DecryptRSA(tlsaux.KeyRing{other}); errors.Cause(err) != tlsaux.ErrNoPrivateKey {
+			t.Fatal(err)
+		}
+
+		// crypto/tls always negotiates the extended master secret.
+		params, err := session.DecryptRSA(tlsaux.KeyRing{other, key})
+		if err != nil || !bytes.Equal(params.MasterSecret, master) {
+			t.Fatal(params, err)
+		}
+	}
+
+	if _, err := new(tlsaux.Session).DecryptRSA(tlsaux.KeyRing{key}); errors.Cause(err) != tlsaux.ErrIncompleteHandshake {
+		t.Fatal(err)
+	}
+}
+
+func TestSession_PMSClientRandom(t *testing.T) {
+	pair, key, _ := rsaKeyPair(t)
+
+	var keylog bytes.Buffer
+	suites := []uint16{tls.TLS_RSA_WITH_AES_128_GCM_SHA256}
+	svconfig := &tls.Config{Certificates: []tls.Certificate{pair}, CipherSuites: suites, MaxVersion: tls.VersionTLS12}
+	clconfig := &tls.Config{InsecureSkipVerify: true, CipherSuites: suites, KeyLogWriter: &keylog}
+
+	clconn, svconn, err := netpipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keep crypto/tls from logging the master secret, the pre-master secret is logged below.
+	var tap io.Writer
+	server, session := tlsaux.CaptureSession(svconn, svconfig, func(conn net.Conn, config *tls.Config) *tls.Conn {
+		tap, config.KeyLogWriter = config.KeyLogWriter, nil
+		return tls.Server(conn, config)
+	})
+	client := tls.Client(clconn, clconfig)
+	defer client.Close()
+	defer server.Close()
+
+	eg := errgroup.Group{}
+	eg.Go(client.Handshake)
+	eg.Go(server.Handshake)
+	if err := eg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	pms, err := rsa.DecryptPKCS1v15(nil, key, session.ClientKeyExchange().EncryptedPreMasterSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := session.SecurityParameters(); v != nil {
+		t.Fatal(v)
+	}
+	fmt.Fprintf(tap, "PMS_CLIENT_RANDOM %x %x\n", session.ClientHello().Random[:], pms)
+
+	_, _, master, err := nsskeylog.Parse(keylog.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := session.SecurityParameters(); v == nil || !bytes.Equal(v.MasterSecret, master) || !bytes.Equal(v.Secrets[nsskeylog.ClientRandom], master) {
+		t.Fatal(v)
+	}
+}
@@ -0,0 +1,461 @@
+package tlsaux
+
+import (
+	"bytes"
+	"crypto/x509"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/maxbet1507/tlsaux/fingerprint"
+	"github.com/maxbet1507/tlsaux/nsskeylog"
+	"github.com/maxbet1507/tlsaux/prf"
+	"github.com/maxbet1507/tlsaux/recordfmt"
+)
+
+// Side -
+type Side int
+
+// -
+const (
+	SideUnknown = Side(iota)
+	SideClient
+	SideServer
+)
+
+func (s Side) String() string {
+	switch s {
+	case SideClient:
+		return "client"
+	case SideServer:
+		return "server"
+	}
+	return "unknown"
+}
+
+// Alert -
+type Alert struct {
+	Sender Side
+	Local  bool
+	Alert  recordfmt.Alert
+}
+
+// Session -
+type Session struct {
+	locker             sync.Mutex
+	clientHello        *recordfmt.ClientHello
+	rawClientHello     []byte
+	initialClientHello *recordfmt.ClientHello
+	helloRetryRequest  *recordfmt.ServerHello
+	serverHello        *recordfmt.ServerHello
+	serverKeyExchange  *recordfmt.ServerKeyExchange
+	clientKeyExchange  *recordfmt.ClientKeyExchange
+	transcript         []byte
+	transcriptDone     bool
+	serverCertificates []*x509.Certificate
+	clientCertificates []*x509.Certificate
+	clientLocal        bool
+	alerts             []Alert
+	secrets            map[nsskeylog.Label][]byte
+	keyLogErr          error
+}
+
+func (s *Session) handleHandshake(local bool, v *recordfmt.Handshake) {
+	n := len(v.Body)
+	raw := append([]byte{byte(v.MsgType), byte(n >> 16), byte(n >> 8), byte(n)}, v.Body...)
+
+	switch v.MsgType {
+	case recordfmt.TypeClientHello:
+		var w recordfmt.ClientHello
+		if err := recordfmt.Unmarshal(v.Body, &w, nil); err == nil {
+			s.handleClientHello(local, &w, raw)
+		}
+		return
+
+	case recordfmt.TypeServerHello:
+		var w recordfmt.ServerHello
+		if err := recordfmt.Unmarshal(v.Body, &w, nil); err == nil {
+			s.handleServerHello(&w)
+		}
+
+	case recordfmt.TypeCertificate:
+		var w recordfmt.Certificate
+		if err := recordfmt.Unmarshal(v.Body, &w, nil); err == nil {
+			s.handleCertificate(local, &w)
+		}
+
+	case recordfmt.TypeServerKeyExchange:
+		s.handleServerKeyExchange(bytes.NewReader(v.Body))
+
+	case recordfmt.TypeClientKeyExchange:
+		s.handleClientKeyExchange(bytes.NewReader(v.Body))
+	}
+	s.handleTranscript(v.MsgType, raw)
+}
+
+func (s *Session) handleTranscript(t recordfmt.HandshakeType, raw []byte) {
+	s.locker.Lock()
+	// the extended master secret hashes everything up to ClientKeyExchange.
+	if s.clientHello != nil && !s.transcriptDone {
+		s.transcript = append(s.transcript, raw...)
+		s.transcriptDone = t == recordfmt.TypeClientKeyExchange
+	}
+	s.locker.Unlock()
+}
+
+func (s *Session) handleClientHello(local bool, v *recordfmt.ClientHello, raw []byte) {
+	s.locker.Lock()
+	if s.helloRetryRequest != nil && s.serverHello == nil {
+		// second ClientHello in reply to HelloRetryRequest.
+		s.initialClientHello = s.clientHello
+	} else {
+		s.initialClientHello = nil
+		s.helloRetryRequest = nil
+		s.secrets = nil
+	}
+	s.clientHello = v
+	s.rawClientHello = raw
+	s.transcript = append([]byte{}, raw...)
+	s.transcriptDone = false
+	s.clientLocal = local
+	s.serverHello = nil
+	s.serverKeyExchange = nil
+	s.clientKeyExchange = nil
+	s.serverCertificates = nil
+	s.clientCertificates = nil
+	s.locker.Unlock()
+}
+
+// an unfinished transcript would hash to a wrong extended master secret.
+func (s *Session) completeTranscript() []byte {
+	if s.transcriptDone {
+		return s.transcript
+	}
+	return nil
+}
+
+func (s *Session) handleServerHello(v *recordfmt.ServerHello) {
+	s.locker.Lock()
+	if v.IsHelloRetryRequest() {
+		s.helloRetryRequest = v
+	} else {
+		s.serverHello = v
+	}
+	s.locker.Unlock()
+}
+
+func (s *Session) handleCertificate(local bool, v *recordfmt.Certificate) {
+	certs, _ := v.X509()
+
+	s.locker.Lock()
+	if local == s.clientLocal {
+		s.clientCertificates = certs
+	} else {
+		s.serverCertificates = certs
+	}
+	s.locker.Unlock()
+}
+
+func (s *Session) handleServerKeyExchange(r io.Reader) {
+	s.locker.Lock()
+	if sh := s.serverHello; sh != nil {
+		var v recordfmt.ServerKeyExchange
+		var fn func(io.Reader, recordfmt.ProtocolVersion) error
+
+		switch sh.CipherSuite.KeyExchange() {
+		case recordfmt.KeyExchangeECDHE:
+			fn = v.DecodeECDHE
+		case recordfmt.KeyExchangeDHE:
+			fn = v.DecodeDHE
+		}
+
+		if fn != nil && fn(r, sh.SelectedVersion()) == nil {
+			s.serverKeyExchange = &v
+		}
+	}
+	s.locker.Unlock()
+}
+
+func (s *Session) handleClientKeyExchange(r io.Reader) {
+	s.locker.Lock()
+	if sh := s.serverHello; sh != nil && sh.CipherSuite.KeyExchange() == recordfmt.KeyExchangeRSA {
+		var v recordfmt.ClientKeyExchange
+		if v.DecodeRSA(r, sh.SelectedVersion()) == nil {
+			s.clientKeyExchange = &v
+		}
+	}
+	s.locker.Unlock()
+}
+
+func (s *Session) handleAlert(local bool, v *recordfmt.Alert) {
+	s.locker.Lock()
+	alert := Alert{Sender: SideUnknown, Local: local, Alert: *v}
+	if s.clientHello != nil {
+		alert.Sender = SideServer
+		if local == s.clientLocal {
+			alert.Sender = SideClient
+		}
+	}
+	s.alerts = append(s.alerts, alert)
+	s.locker.Unlock()
+}
+
+func (s *Session) handleChangeCipherSpec() (r bool) {
+	s.locker.Lock()
+	r = s.serverHello != nil
+	s.locker.Unlock()
+	return
+}
+
+func (s *Session) handleNSSKeyLog(label nsskeylog.Label, crand, secret []byte) {
+	s.locker.Lock()
+	if s.clientHello != nil && bytes.Equal(s.clientHello.Random, crand) {
+		// a secret that cannot belong to the negotiated suite is kept out, the
+		// first refusal is kept for KeyLogError.
+		var err error
+		if s.serverHello != nil {
+			err = nsskeylog.ValidateSecret(label, secret, uint16(s.serverHello.CipherSuite))
+		}
+		if err != nil {
+			if s.keyLogErr == nil {
+				s.keyLogErr = err
+			}
+		} else {
+			if s.secrets == nil {
+				s.secrets = map[nsskeylog.Label][]byte{}
+			}
+			s.secrets[label] = secret
+		}
+	}
+	s.locker.Unlock()
+}
+
+// ClientHello -
+func (s *Session) ClientHello() (r *recordfmt.ClientHello) {
+	s.locker.Lock()
+	r = s.clientHello
+	s.locker.Unlock()
+	return
+}
+
+// RawClientHello -
+// an SSLv2 compatible hello is kept as its equivalent TLS encoding, not the
+// bytes on the wire. it carries no extensions, so no extended master secret
+// is ever derived from that transcript.
+func (s *Session) RawClientHello() (r []byte) {
+	s.locker.Lock()
+	r = s.rawClientHello
+	s.locker.Unlock()
+	return
+}
+
+// InitialClientHello -
+func (s *Session) InitialClientHello() (r *recordfmt.ClientHello) {
+	s.locker.Lock()
+	r = s.initialClientHello
+	s.locker.Unlock()
+	return
+}
+
+// HelloRetryRequest -
+func (s *Session) HelloRetryRequest() (r *recordfmt.ServerHello) {
+	s.locker.Lock()
+	r = s.helloRetryRequest
+	s.locker.Unlock()
+	return
+}
+
+// ServerHello -
+func (s *Session) ServerHello() (r *recordfmt.ServerHello) {
+	s.locker.Lock()
+	r = s.serverHello
+	s.locker.Unlock()
+	return
+}
+
+// ServerCertificates -
+func (s *Session) ServerCertificates() (r []*x509.Certificate) {
+	s.locker.Lock()
+	r = s.serverCertificates
+	s.locker.Unlock()
+	return
+}
+
+// ClientCertificates -
+func (s *Session) ClientCertificates() (r []*x509.Certificate) {
+	s.locker.Lock()
+	r = s.clientCertificates
+	s.locker.Unlock()
+	return
+}
+
+// ServerKeyExchange -
+func (s *Session) ServerKeyExchange() (r *recordfmt.ServerKeyExchange) {
+	s.locker.Lock()
+	r = s.serverKeyExchange
+	s.locker.Unlock()
+	return
+}
+
+// ClientKeyExchange -
+func (s *Session) ClientKeyExchange() (r *recordfmt.ClientKeyExchange) {
+	s.locker.Lock()
+	r = s.clientKeyExchange
+	s.locker.Unlock()
+	return
+}
+
+// Alerts -
+func (s *Session) Alerts() (r []Alert) {
+	s.locker.Lock()
+	r = append(r, s.alerts...)
+	s.locker.Unlock()
+	return
+}
+
+// JA3 -
+func (s *Session) JA3() (r *fingerprint.Fingerprint) {
+	s.locker.Lock()
+	if s.clientHello != nil {
+		v := fingerprint.JA3(s.clientHello)
+		r = &v
+	}
+	s.locker.Unlock()
+	return
+}
+
+// JA3S -
+func (s *Session) JA3S() (r *fingerprint.Fingerprint) {
+	s.locker.Lock()
+	if s.serverHello != nil {
+		v := fingerprint.JA3S(s.serverHello)
+		r = &v
+	}
+	s.locker.Unlock()
+	return
+}
+
+// JA4 -
+func (s *Session) JA4() (r *fingerprint.Variants) {
+	s.locker.Lock()
+	if s.clientHello != nil {
+		v := fingerprint.JA4(s.clientHello)
+		r = &v
+	}
+	s.locker.Unlock()
+	return
+}
+
+// JA4S -
+func (s *Session) JA4S() (r *fingerprint.Variants) {
+	s.locker.Lock()
+	if s.serverHello != nil {
+		v := fingerprint.JA4S(s.serverHello)
+		r = &v
+	}
+	s.locker.Unlock()
+	return
+}
+
+// JA4X -
+func (s *Session) JA4X() (r *fingerprint.Variants) {
+	s.locker.Lock()
+	if len(s.serverCertificates) > 0 {
+		v := fingerprint.JA4X(s.serverCertificates[0])
+		r = &v
+	}
+	s.locker.Unlock()
+	return
+}
+
+// KeyLogError -
+func (s *Session) KeyLogError() (err error) {
+	s.locker.Lock()
+	err = s.keyLogErr
+	s.locker.Unlock()
+	return
+}
+
+// ValidateSecrets -
+func (s *Session) ValidateSecrets() (err error) {
+	s.locker.Lock()
+	if err = s.keyLogErr; err == nil && s.serverHello != nil {
+		labels := []nsskeylog.Label{}
+		for k := range s.secrets {
+			labels = append(labels, k)
+		}
+		sort.Slice(labels, func(i, j int) bool { return labels[i] < labels[j] })
+
+		suite := uint16(s.serverHello.CipherSuite)
+		for i := 0; i < len(labels) && err == nil; i++ {
+			err = nsskeylog.ValidateSecret(labels[i], s.secrets[labels[i]], suite)
+		}
+	}
+	s.locker.Unlock()
+	return
+}
+
+// Secrets -
+func (s *Session) Secrets() (r map[nsskeylog.Label][]byte) {
+	s.locker.Lock()
+	if len(s.secrets) > 0 {
+		r = map[nsskeylog.Label][]byte{}
+		for k, v := range s.secrets {
+			r[k] = v
+		}
+	}
+	s.locker.Unlock()
+	return
+}
+
+// SecurityParameters -
+func (s *Session) SecurityParameters() (r *SecurityParameters) {
+	s.locker.Lock()
+	if s.clientHello != nil && s.serverHello != nil && len(s.secrets) > 0 {
+		version := int(s.serverHello.SelectedVersion())
+		suite := uint16(s.serverHello.CipherSuite)
+
+		secrets := map[nsskeylog.Label][]byte{}
+		for k, v := range s.secrets {
+			secrets[k] = v
+		}
+
+		// a pre-master secret is as good as the master secret once derived.
+		if pms, ok := secrets[nsskeylog.PMSClientRandom]; ok && secrets[nsskeylog.ClientRandom] == nil {
+			if v, err := newSecurityParameters(s.clientHello, s.serverHello, pms, s.completeTranscript()); err == nil {
+				secrets[nsskeylog.ClientRandom] = v.MasterSecret
+			}
+		}
+
+		// without a master secret and a PRF there is nothing to offer, TLS 1.3 included.
+		fn, err := prf.New(version, suite)
+		if master := secrets[nsskeylog.ClientRandom]; master != nil && err == nil {
+			r = &SecurityParameters{
+				PRF:               fn,
+				Version:           version,
+				CipherSuite:       suite,
+				CompressionMethod: uint8(s.serverHello.CompressionMethod),
+				MasterSecret:      master,
+				ClientRandom:      s.clientHello.Random[:],
+				ServerRandom:      s.serverHello.Random[:],
+				Secrets:           secrets,
+			}
+		}
+	}
+	s.locker.Unlock()
+	return
+}
+
+// WriteKeyLog -
+func (s *Session) WriteKeyLog(w *nsskeylog.Writer) (err error) {
+	if v := s.SecurityParameters(); v != nil {
+		return v.WriteKeyLog(w)
+	}
+
+	s.locker.Lock()
+	if s.clientHello != nil {
+		err = writeKeyLog(w, s.clientHello.Random[:], s.secrets)
+	}
+	s.locker.Unlock()
+	return
+}
@@ -0,0 +1,482 @@
+package tlsaux_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxbet1507/tlsaux"
+	"github.com/maxbet1507/tlsaux/fingerprint"
+	"github.com/maxbet1507/tlsaux/nsskeylog"
+	"github.com/maxbet1507/tlsaux/recordfmt"
+	"github.com/maxbet1507/tlsaux/testcert"
+	"github.com/maxbet1507/tlsaux/verify"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+func handshake(t *testing.T, clconfig, svconfig *tls.Config) (client, server *tls.Conn, clsession, svsession *tlsaux.Session) {
+	clconn, svconn, err := netpipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, clsession = tlsaux.CaptureSession(clconn, clconfig, tls.Client)
+	server, svsession = tlsaux.CaptureSession(svconn, svconfig, tls.Server)
+
+	eg := errgroup.Group{}
+	eg.Go(client.Handshake)
+	eg.Go(server.Handshake)
+
+	if err := eg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestSession_HelloRetryRequest(t *testing.T) {
+	cert, pkey, _ := testcert.SelfSigned(1024, 10*time.Second)
+	pair, _ := tls.X509KeyPair(cert, pkey)
+
+	svconfig := &tls.Config{
+		Certificates:     []tls.Certificate{pair},
+		CurvePreferences: []tls.CurveID{tls.CurveP256},
+	}
+	clconfig := &tls.Config{
+		InsecureSkipVerify: true,
+		CurvePreferences:   []tls.CurveID{tls.X25519, tls.CurveP256},
+	}
+
+	client, server, clsession, svsession := handshake(t, clconfig, svconfig)
+	defer client.Close()
+	defer server.Close()
+
+	for _, session := range []*tlsaux.Session{clsession, svsession} {
+		hrr := session.HelloRetryRequest()
+		if hrr == nil || !hrr.IsHelloRetryRequest() {
+			t.Fatal(hrr)
+		}
+		if group, ok := hrr.SelectedGroup(); !ok || group != 0x0017 {
+			t.Fatal(group, ok)
+		}
+
+		initial, final := session.InitialClientHello(), session.ClientHello()
+		if initial == nil || final == nil || initial == final {
+			t.Fatal(initial, final)
+		}
+		if sh := session.ServerHello(); sh == nil || sh.IsHelloRetryRequest() || sh.SelectedVersion() != tls.VersionTLS13 {
+			t.Fatal(sh)
+		}
+	}
+
+	// TLS 1.3 has no master secret, its secrets are available on their own.
+	if clparams, svparams := clsession.SecurityParameters(), svsession.SecurityParameters(); clparams != nil || svparams != nil {
+		t.Fatal(clparams, svparams)
+	}
+
+	cl, sv := clsession.Secrets()[nsskeylog.ClientTrafficSecret0], svsession.Secrets()[nsskeylog.ClientTrafficSecret0]
+	if len(cl) == 0 || bytes.Compare(cl, sv) != 0 {
+		t.Fatal(cl, sv)
+	}
+}
+
+func TestSession_WriteKeyLog(t *testing.T) {
+	cert, pkey, _ := testcert.SelfSigned(1024, 10*time.Second)
+	pair, _ := tls.X509KeyPair(cert, pkey)
+
+	for _, version := range []uint16{tls.VersionTLS12, tls.VersionTLS13} {
+		var original bytes.Buffer
+
+		svconfig := &tls.Config{Certificates: []tls.Certificate{pair}}
+		clconfig := &tls.Config{InsecureSkipVerify: true, MaxVersion: version, KeyLogWriter: &original}
+
+		client, server, clsession, _ := handshake(t, clconfig, svconfig)
+		client.Close()
+		server.Close()
+
+		var regenerated bytes.Buffer
+		if err := clsession.WriteKeyLog(nsskeylog.NewWriter(&regenerated)); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := strings.Split(strings.TrimSpace(original.String()), "\n")
+		actual := strings.Split(strings.TrimSpace(regenerated.String()), "\n")
+		sort.Strings(expected)
+		sort.Strings(actual)
+		if strings.Join(expected, "\n") != strings.Join(actual, "\n") {
+			t.Fatal(version, expected, actual)
+		}
+	}
+}
+
+func TestSession_ValidateSecrets(t *testing.T) {
+	cert, pkey, _ := testcert.SelfSigned(1024, 10*time.Second)
+	pair, _ := tls.X509KeyPair(cert, pkey)
+
+	clconn, svconn, err := netpipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tap io.Writer
+	client, session := tlsaux.CaptureSession(clconn, &tls.Config{InsecureSkipVerify: true}, func(conn net.Conn, config *tls.Config) *tls.Conn {
+		tap = config.KeyLogWriter
+		return tls.Client(conn, config)
+	})
+	server := tls.Server(svconn, &tls.Config{Certificates: []tls.Certificate{pair}})
+	defer client.Close()
+	defer server.Close()
+
+	eg := errgroup.Group{}
+	eg.Go(client.Handshake)
+	eg.Go(server.Handshake)
+	if err := eg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	// crypto/tls picks the TLS 1.3 suite itself, the check must follow it.
+	mismatch := 0
+	switch suite := client.ConnectionState().CipherSuite; suite {
+	case tls.TLS_AES_128_GCM_SHA256, tls.TLS_CHACHA20_POLY1305_SHA256:
+		mismatch = 48
+	case tls.TLS_AES_256_GCM_SHA384:
+		mismatch = 32
+	default:
+		t.Fatal(suite)
+	}
+	if uint16(session.ServerHello().CipherSuite) != client.ConnectionState().CipherSuite {
+		t.Fatal(session.ServerHello())
+	}
+
+	if err := session.ValidateSecrets(); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.KeyLogError(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a mismatched secret is refused as the key log is correlated.
+	exporter := session.Secrets()[nsskeylog.ExporterSecret]
+	fmt.Fprintf(tap, "EXPORTER_SECRET %x %x\n", session.ClientHello().Random[:], make([]byte, mismatch))
+	if err := session.KeyLogError(); errors.Cause(err) != nsskeylog.ErrSecretLength {
+		t.Fatal(err)
+	}
+	if v := session.Secrets()[nsskeylog.ExporterSecret]; !bytes.Equal(v, exporter) {
+		t.Fatal(v)
+	}
+	if err := session.ValidateSecrets(); errors.Cause(err) != nsskeylog.ErrSecretLength {
+		t.Fatal(err)
+	}
+}
+
+func TestSession_TLS12(t *testing.T) {
+	cert, pkey, _ := testcert.SelfSigned(1024, 10*time.Second)
+	pair, _ := tls.X509KeyPair(cert, pkey)
+
+	svconfig := &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		MaxVersion:   tls.VersionTLS12,
+	}
+	clconfig := &tls.Config{
+		InsecureSkipVerify: true,
+	}
+
+	client, server, clsession, svsession := handshake(t, clconfig, svconfig)
+	defer client.Close()
+	defer server.Close()
+
+	for _, session := range []*tlsaux.Session{clsession, svsession} {
+		if session.HelloRetryRequest() != nil || session.InitialClientHello() != nil {
+			t.Fatal(session)
+		}
+	}
+
+	clparams, svparams := clsession.SecurityParameters(), svsession.SecurityParameters()
+	if clparams == nil || svparams == nil || clparams.Version != tls.VersionTLS12 {
+		t.Fatal(clparams, svparams)
+	}
+	if len(clparams.MasterSecret) != 48 || bytes.Compare(clparams.MasterSecret, svparams.MasterSecret) != 0 {
+		t.Fatal(clparams, svparams)
+	}
+}
+
+func TestSession_Fingerprints(t *testing.T) {
+	cert, pkey, _ := testcert.SelfSigned(1024, 10*time.Second)
+	pair, _ := tls.X509KeyPair(cert, pkey)
+
+	svconfig := &tls.Config{Certificates: []tls.Certificate{pair}}
+	clconfig := &tls.Config{InsecureSkipVerify: true}
+
+	client, server, clsession, svsession := handshake(t, clconfig, svconfig)
+	defer client.Close()
+	defer server.Close()
+
+	clja3, svja3 := clsession.JA3(), svsession.JA3()
+	if clja3 == nil || svja3 == nil || *clja3 != *svja3 || len(clja3.Hash) != 32 {
+		t.Fatal(clja3, svja3)
+	}
+	clja3s, svja3s := clsession.JA3S(), svsession.JA3S()
+	if clja3s == nil || svja3s == nil || *clja3s != *svja3s || !strings.HasPrefix(clja3s.Text, "771,") {
+		t.Fatal(clja3s, svja3s)
+	}
+
+	clja4, svja4 := clsession.JA4(), svsession.JA4()
+	if clja4 == nil || svja4 == nil || *clja4 != *svja4 || !strings.HasPrefix(clja4.Hashed, "t13i") {
+		t.Fatal(clja4, svja4)
+	}
+	clja4s, svja4s := clsession.JA4S(), svsession.JA4S()
+	if clja4s == nil || svja4s == nil || *clja4s != *svja4s || !strings.HasPrefix(clja4s.Hashed, "t13") {
+		t.Fatal(clja4s, svja4s)
+	}
+
+	// TLS 1.3 encrypts the certificates.
+	if clja4x, svja4x := clsession.JA4X(), svsession.JA4X(); clja4x != nil || svja4x != nil {
+		t.Fatal(clja4x, svja4x)
+	}
+}
+
+func TestSession_Certificates(t *testing.T) {
+	cert, pkey, _ := testcert.SelfSigned(1024, 10*time.Second)
+	pair, _ := tls.X509KeyPair(cert, pkey)
+
+	svconfig := &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+	}
+	clconfig := &tls.Config{
+		InsecureSkipVerify: true,
+	}
+
+	client, server, clsession, svsession := handshake(t, clconfig, svconfig)
+	defer client.Close()
+	defer server.Close()
+
+	for _, session := range []*tlsaux.Session{clsession, svsession} {
+		certs := session.ServerCertificates()
+		if len(certs) != 1 || len(session.ClientCertificates()) != 0 {
+			t.Fatal(certs)
+		}
+
+		roots := x509.NewCertPool()
+		roots.AddCert(certs[0])
+		if _, err := verify.Chain(certs, roots, "localhost", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		if ja4x := session.JA4X(); ja4x == nil || *ja4x != fingerprint.JA4X(certs[0]) {
+			t.Fatal(ja4x)
+		}
+
+		ske := session.ServerKeyExchange()
+		if ske == nil {
+			t.Fatal(ske)
+		}
+		ch, sh := session.ClientHello(), session.ServerHello()
+		if err := verify.ServerKeyExchange(certs[0], int(sh.SelectedVersion()), ch.Random, sh.Random, ske); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSession_Alerts(t *testing.T) {
+	cert, pkey, _ := testcert.SelfSigned(1024, 10*time.Second)
+	pair, _ := tls.X509KeyPair(cert, pkey)
+
+	svconfig := &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		MaxVersion:   tls.VersionTLS12,
+	}
+	clconfig := &tls.Config{
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS13,
+	}
+
+	clconn, svconn, err := netpipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, clsession := tlsaux.CaptureSession(clconn, clconfig, tls.Client)
+	server, svsession := tlsaux.CaptureSession(svconn, svconfig, tls.Server)
+	defer client.Close()
+	defer server.Close()
+
+	eg := errgroup.Group{}
+	eg.Go(client.Handshake)
+	eg.Go(server.Handshake)
+
+	if err := eg.Wait(); err == nil {
+		t.Fatal(err)
+	}
+
+	for session, local := range map[*tlsaux.Session]bool{clsession: false, svsession: true} {
+		alerts := session.Alerts()
+		if len(alerts) != 1 {
+			t.Fatal(alerts)
+		}
+		if v := alerts[0]; v.Sender != tlsaux.SideServer || v.Local != local || v.Alert.Description != recordfmt.AlertProtocolVersion {
+			t.Fatal(v)
+		}
+	}
+}
+
+func TestSession_SSLv2ClientHello(t *testing.T) {
+	clconn, svconn, err := netpipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clconn.Close()
+
+	cert, pkey, _ := testcert.SelfSigned(1024, 10*time.Second)
+	pair, _ := tls.X509KeyPair(cert, pkey)
+
+	server, svsession := tlsaux.CaptureSession(svconn, &tls.Config{Certificates: []tls.Certificate{pair}}, tls.Server)
+	defer server.Close()
+
+	go clconn.Write([]byte{
+		0x80, 0x22, 0x01, 0x03, 0x01, 0x00, 0x09, 0x00, 0x00, 0x00, 0x10,
+		0x07, 0x00, 0xc0, 0x00, 0x00, 0x2f, 0x00, 0x00, 0x0a,
+		0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17,
+		0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+	})
+
+	// crypto/tls refuses SSLv2 hellos, but the capture still sees it.
+	if err := server.Handshake(); err == nil {
+		t.Fatal(err)
+	}
+
+	hello := svsession.ClientHello()
+	if hello == nil || hello.ClientVersion != tls.VersionTLS10 || len(hello.CipherSuites) != 2 {
+		t.Fatal(hello)
+	}
+	if hello.Random[15] != 0x00 || hello.Random[16] != 0x10 {
+		t.Fatal(hello.Random)
+	}
+}
+
+func TestSession_SSLv2ClientHelloNotFirst(t *testing.T) {
+	clconn, svconn, err := netpipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clconn.Close()
+
+	server, svsession := tlsaux.CaptureSession(svconn, &tls.Config{}, tls.Server)
+	defer server.Close()
+
+	// a warning alert, then an SSLv2 hello that must not be taken.
+	go clconn.Write([]byte{
+		0x15, 0x03, 0x01, 0x00, 0x02, 0x01, 0x5a,
+		0x80, 0x22, 0x01, 0x03, 0x01, 0x00, 0x09, 0x00, 0x00, 0x00, 0x10,
+		0x07, 0x00, 0xc0, 0x00, 0x00, 0x2f, 0x00, 0x00, 0x0a,
+		0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17,
+		0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+	})
+
+	if err := server.Handshake(); err == nil {
+		t.Fatal(err)
+	}
+	if hello := svsession.ClientHello(); hello != nil {
+		t.Fatal(hello)
+	}
+}
+
+func handshakeRecord(typ recordfmt.HandshakeType, body []byte) []byte {
+	n := len(body) + 4
+	r := []byte{0x16, 0x03, 0x03, byte(n >> 8), byte(n), byte(typ), byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+	return append(r, body...)
+}
+
+func TestSession_DHEServerKeyExchange(t *testing.T) {
+	_, key, leaf := rsaKeyPair(t)
+	crand, srand := bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x02}, 32)
+
+	// crypto/tls has no DHE, so play both sides by hand.
+	ch := append(append([]byte{0x03, 0x03}, crand...), 0x00, 0x00, 0x02, 0x00, 0x9e, 0x01, 0x00)
+	sh := append(append([]byte{0x03, 0x03}, srand...), 0x00, 0x00, 0x9e, 0x00)
+	params := []byte{0x00, 0x01, 0x17, 0x00, 0x01, 0x05, 0x00, 0x01, 0x0b}
+
+	digest := sha256.Sum256(bytes.Join([][]byte{crand, srand, params}, nil))
+	sig, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ske := append(append(append([]byte{}, params...), 0x04, 0x01, byte(len(sig)>>8), byte(len(sig))), sig...)
+
+	clconn, svconn := net.Pipe()
+	defer clconn.Close()
+	defer svconn.Close()
+
+	var aux net.Conn
+	_, session := tlsaux.CaptureSession(clconn, &tls.Config{}, func(conn net.Conn, _ *tls.Config) *tls.Conn {
+		aux = conn
+		return nil
+	})
+
+	go io.Copy(ioutil.Discard, svconn)
+	if _, err := aux.Write(handshakeRecord(recordfmt.TypeClientHello, ch)); err != nil {
+		t.Fatal(err)
+	}
+
+	flight := append(handshakeRecord(recordfmt.TypeServerHello, sh), handshakeRecord(recordfmt.TypeServerKeyExchange, ske)...)
+	go svconn.Write(flight)
+	if _, err := io.ReadFull(aux, make([]byte, len(flight))); err != nil {
+		t.Fatal(err)
+	}
+
+	v := session.ServerKeyExchange()
+	if v == nil {
+		t.Fatal(v)
+	}
+	if p, err := v.DHParams(); err != nil || !bytes.Equal(p.Ys, []byte{0x0b}) {
+		t.Fatal(p, err)
+	}
+	if err := verify.ServerKeyExchange(leaf, tls.VersionTLS12, crand, srand, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSession_OversizedRecord(t *testing.T) {
+	clconn, svconn := net.Pipe()
+	defer clconn.Close()
+	defer svconn.Close()
+
+	var aux net.Conn
+	_, session := tlsaux.CaptureSession(clconn, &tls.Config{}, func(conn net.Conn, _ *tls.Config) *tls.Conn {
+		aux = conn
+		return nil
+	})
+	go io.Copy(ioutil.Discard, svconn)
+
+	// the payload of a record too large to decode must not be taken for records.
+	alert := []byte{0x15, 0x03, 0x03, 0x00, 0x02, 0x02, 0x28}
+	n := recordfmt.DefaultDecodeOptions.MaxFragmentLength + 1
+	payload := append(bytes.Repeat(alert, n/len(alert)), make([]byte, n%len(alert))...)
+	oversized := append([]byte{0x16, 0x03, 0x03, byte(n >> 8), byte(n)}, payload...)
+
+	crand := bytes.Repeat([]byte{0x01}, 32)
+	ch := append(append([]byte{0x03, 0x03}, crand...), 0x00, 0x00, 0x02, 0x00, 0x2f, 0x01, 0x00)
+
+	if _, err := aux.Write(append(oversized, handshakeRecord(recordfmt.TypeClientHello, ch)...)); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := session.Alerts(); len(v) != 0 {
+		t.Fatal(len(v))
+	}
+	if v := session.ClientHello(); v == nil || !bytes.Equal(v.Random, crand) {
+		t.Fatal(v)
+	}
+}
@@ -0,0 +1,8 @@
+package tlsaux
+
+func assert(f bool, err error) error {
+	if f {
+		err = nil
+	}
+	return err
+}
@@ -0,0 +1,160 @@
+package conform
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+)
+
+// Rule -
+type Rule string
+
+// -
+const (
+	RuleSessionIDLength          = Rule("session_id_length")
+	RuleSessionIDEcho            = Rule("session_id_echo")
+	RuleCipherSuites             = Rule("cipher_suites")
+	RuleCompressionMethod        = Rule("compression_method")
+	RuleDuplicateExtension       = Rule("duplicate_extension")
+	RuleUnsolicitedExtension     = Rule("unsolicited_extension")
+	RuleMissingSupportedVersions = Rule("missing_supported_versions")
+	RuleSupportedVersions        = Rule("supported_versions")
+	RuleLegacyVersion            = Rule("legacy_version")
+	RulePreSharedKeyPosition     = Rule("pre_shared_key_position")
+	RuleGREASE                   = Rule("grease")
+)
+
+// Violation -
+type Violation struct {
+	Rule    Rule
+	Message string
+}
+
+func (s Violation) Error() string {
+	return string(s.Rule) + ": " + s.Message
+}
+
+type violations []Violation
+
+func (s *violations) add(f bool, rule Rule, format string, args ...interface{}) {
+	if f {
+		*s = append(*s, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+func duplicates(s *violations, exts recordfmt.HelloExtensions) {
+	seen := map[recordfmt.ExtensionType]bool{}
+	for _, v := range exts {
+		s.add(seen[v.ExtensionType], RuleDuplicateExtension, "extension %d appears more than once", v.ExtensionType)
+		seen[v.ExtensionType] = true
+	}
+}
+
+func offersTLS13(ch *recordfmt.ClientHello) bool {
+	for _, v := range ch.SupportedVersions() {
+		if v == tls.VersionTLS13 {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientHello -
+func ClientHello(ch *recordfmt.ClientHello) []Violation {
+	var s violations
+
+	s.add(len(ch.SessionID) > 32, RuleSessionIDLength, "session id is %d bytes", len(ch.SessionID))
+	s.add(len(ch.CipherSuites) == 0, RuleCipherSuites, "no cipher suites offered")
+
+	null := false
+	for _, v := range ch.CompressionMethods {
+		null = null || v == 0
+	}
+	s.add(!null, RuleCompressionMethod, "null compression not offered")
+	s.add(offersTLS13(ch) && len(ch.CompressionMethods) != 1, RuleCompressionMethod, "TLS 1.3 requires only null compression")
+
+	duplicates(&s, ch.Extensions)
+
+	_, keyShare := ch.Extensions.Find(recordfmt.ExtensionKeyShare)
+	s.add(keyShare && !offersTLS13(ch), RuleMissingSupportedVersions, "key_share without TLS 1.3 in supported_versions")
+
+	for i, v := range ch.Extensions {
+		s.add(v.ExtensionType == recordfmt.ExtensionPreSharedKey && i != len(ch.Extensions)-1, RulePreSharedKeyPosition, "pre_shared_key is not the last extension")
+	}
+
+	// GREASE values must never be the only thing offered.
+	usable := 0
+	for _, v := range ch.CipherSuites {
+		if !recordfmt.IsGREASE(uint16(v)) {
+			usable++
+		}
+	}
+	s.add(len(ch.CipherSuites) > 0 && usable == 0, RuleGREASE, "only GREASE cipher suites offered")
+	s.add(recordfmt.IsGREASE(uint16(ch.ClientVersion)), RuleGREASE, "GREASE legacy version %#04x", int(ch.ClientVersion))
+
+	return s
+}
+
+// ServerHello -
+func ServerHello(sh *recordfmt.ServerHello, ch *recordfmt.ClientHello) []Violation {
+	var s violations
+
+	s.add(len(sh.SessionID) > 32, RuleSessionIDLength, "session id is %d bytes", len(sh.SessionID))
+	duplicates(&s, sh.Extensions)
+
+	if sh.IsTLS13() {
+		s.add(sh.ServerVersion != tls.VersionTLS12, RuleLegacyVersion, "legacy version %#04x in TLS 1.3", int(sh.ServerVersion))
+		s.add(sh.CompressionMethod != 0, RuleCompressionMethod, "compression method %d in TLS 1.3", sh.CompressionMethod)
+	}
+	for _, v := range sh.Extensions {
+		s.add(recordfmt.IsGREASE(uint16(v.ExtensionType)), RuleGREASE, "GREASE extension %#04x selected", uint16(v.ExtensionType))
+	}
+	s.add(recordfmt.IsGREASE(uint16(sh.CipherSuite)), RuleGREASE, "GREASE cipher suite %#04x selected", uint16(sh.CipherSuite))
+	s.add(recordfmt.IsGREASE(uint16(sh.SelectedVersion())), RuleGREASE, "GREASE version %#04x selected", int(sh.SelectedVersion()))
+
+	// a capture may start after the ClientHello, nothing to hold the echoes to.
+	if ch != nil {
+		echoes(&s, sh, ch)
+	}
+	return s
+}
+
+func echoes(s *violations, sh *recordfmt.ServerHello, ch *recordfmt.ClientHello) {
+	if sh.IsTLS13() {
+		s.add(!bytes.Equal(sh.SessionID, ch.SessionID), RuleSessionIDEcho, "legacy session id not echoed")
+
+		offered := false
+		for _, v := range ch.SupportedVersions() {
+			offered = offered || v == sh.SelectedVersion()
+		}
+		s.add(!offered, RuleSupportedVersions, "version %#04x not offered", int(sh.SelectedVersion()))
+	} else {
+		offered := false
+		for _, v := range ch.CompressionMethods {
+			offered = offered || v == sh.CompressionMethod
+		}
+		s.add(!offered, RuleCompressionMethod, "compression method %d not offered", sh.CompressionMethod)
+	}
+
+	offered := false
+	for _, v := range ch.CipherSuites {
+		offered = offered || v == sh.CipherSuite
+	}
+	s.add(!offered, RuleCipherSuites, "cipher suite %#04x not offered", uint16(sh.CipherSuite))
+
+	scsv := false
+	for _, v := range ch.CipherSuites {
+		scsv = scsv || v == 0x00ff
+	}
+	for _, v := range sh.Extensions {
+		_, ok := ch.Extensions.Find(v.ExtensionType)
+		switch {
+		case v.ExtensionType == recordfmt.ExtensionCookie && sh.IsHelloRetryRequest():
+		case v.ExtensionType == recordfmt.ExtensionRenegotiationInfo && scsv:
+		default:
+			s.add(!ok, RuleUnsolicitedExtension, "extension %d not offered", v.ExtensionType)
+		}
+	}
+}
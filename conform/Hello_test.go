@@ -0,0 +1,161 @@
+package conform_test
+
+import (
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/conform"
+	"github.com/maxbet1507/tlsaux/recordfmt"
+)
+
+func rules(v []conform.Violation) map[conform.Rule]int {
+	r := map[conform.Rule]int{}
+	for _, v := range v {
+		r[v.Rule]++
+	}
+	return r
+}
+
+func clientHello() *recordfmt.ClientHello {
+	return &recordfmt.ClientHello{
+		ClientVersion:      0x0303,
+		Random:             make(recordfmt.Random, 32),
+		SessionID:          make(recordfmt.SessionID, 32),
+		CipherSuites:       recordfmt.CipherSuites{0x1a1a, 0x1301, 0xc02f},
+		CompressionMethods: recordfmt.CompressionMethods{0},
+		Extensions: recordfmt.HelloExtensions{
+			{ExtensionType: 0x2a2a},
+			{ExtensionType: recordfmt.ExtensionSupportedVersions, ExtensionData: []byte{0x04, 0x03, 0x04, 0x03, 0x03}},
+			{ExtensionType: recordfmt.ExtensionKeyShare, ExtensionData: []byte{0x00, 0x00}},
+		},
+	}
+}
+
+func serverHello() *recordfmt.ServerHello {
+	return &recordfmt.ServerHello{
+		ServerVersion: 0x0303,
+		Random:        make(recordfmt.Random, 32),
+		SessionID:     make(recordfmt.SessionID, 32),
+		CipherSuite:   0x1301,
+		Extensions: recordfmt.HelloExtensions{
+			{ExtensionType: recordfmt.ExtensionSupportedVersions, ExtensionData: []byte{0x03, 0x04}},
+			{ExtensionType: recordfmt.ExtensionKeyShare, ExtensionData: []byte{0x00, 0x1d}},
+		},
+	}
+}
+
+func TestClientHello(t *testing.T) {
+	if v := conform.ClientHello(clientHello()); len(v) != 0 {
+		t.Fatal(v)
+	}
+
+	ch := clientHello()
+	ch.SessionID = make(recordfmt.SessionID, 33)
+	ch.CipherSuites = recordfmt.CipherSuites{0x1a1a}
+	ch.CompressionMethods = recordfmt.CompressionMethods{1}
+	ch.Extensions = append(ch.Extensions[2:],
+		recordfmt.HelloExtension{ExtensionType: recordfmt.ExtensionPreSharedKey},
+		recordfmt.HelloExtension{ExtensionType: recordfmt.ExtensionKeyShare},
+	)
+
+	r := rules(conform.ClientHello(ch))
+	expected := map[conform.Rule]int{
+		conform.RuleSessionIDLength:          1,
+		conform.RuleCompressionMethod:        1,
+		conform.RuleDuplicateExtension:       1,
+		conform.RuleMissingSupportedVersions: 1,
+		conform.RulePreSharedKeyPosition:     1,
+		conform.RuleGREASE:                   1,
+	}
+	if len(r) != len(expected) {
+		t.Fatal(r)
+	}
+	for k, v := range expected {
+		if r[k] != v {
+			t.Fatal(k, r)
+		}
+	}
+}
+
+func TestServerHello(t *testing.T) {
+	if v := conform.ServerHello(serverHello(), clientHello()); len(v) != 0 {
+		t.Fatal(v)
+	}
+
+	sh := serverHello()
+	sh.SessionID = nil
+	sh.CipherSuite = 0x1a1a
+	sh.CompressionMethod = 1
+	sh.Extensions = append(sh.Extensions, recordfmt.HelloExtension{ExtensionType: 0x0000})
+
+	r := rules(conform.ServerHello(sh, clientHello()))
+	expected := map[conform.Rule]int{
+		conform.RuleSessionIDEcho:        1,
+		conform.RuleCompressionMethod:    1,
+		conform.RuleUnsolicitedExtension: 1,
+		conform.RuleGREASE:               1,
+	}
+	if len(r) != len(expected) {
+		t.Fatal(r)
+	}
+	for k, v := range expected {
+		if r[k] != v {
+			t.Fatal(k, r)
+		}
+	}
+}
+
+func TestServerHello_TLS12(t *testing.T) {
+	sh := serverHello()
+	sh.CipherSuite = 0xc02f
+	sh.CompressionMethod = 1
+	sh.Extensions = recordfmt.HelloExtensions{
+		{ExtensionType: recordfmt.ExtensionRenegotiationInfo, ExtensionData: []byte{0x00}},
+	}
+
+	r := rules(conform.ServerHello(sh, clientHello()))
+	if len(r) != 2 || r[conform.RuleCompressionMethod] != 1 || r[conform.RuleUnsolicitedExtension] != 1 {
+		t.Fatal(r)
+	}
+}
+
+func TestServerHello_NoClientHello(t *testing.T) {
+	sh := serverHello()
+	sh.SessionID = nil
+	sh.CipherSuite = 0x1a1a
+	sh.CompressionMethod = 1
+
+	// only the rules that need no ClientHello apply.
+	r := rules(conform.ServerHello(sh, nil))
+	if len(r) != 2 || r[conform.RuleCompressionMethod] != 1 || r[conform.RuleGREASE] != 1 {
+		t.Fatal(r)
+	}
+}
+
+func TestClientHello_TLS10(t *testing.T) {
+	raw := []byte{
+		// client version
+		0x03, 0x01,
+		// client random
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		// session id
+		0x00,
+		// cipher suites
+		0x00, 0x02, 0x00, 0x2f,
+		// compression methods
+		0x01, 0x00,
+		// extensions length
+		0x00, 0x0a,
+		// renegotiation_info, twice
+		0xff, 0x01, 0x00, 0x01, 0x00,
+		0xff, 0x01, 0x00, 0x01, 0x00,
+	}
+
+	var ch recordfmt.ClientHello
+	if err := recordfmt.Unmarshal(raw, &ch, nil); err != nil {
+		t.Fatal(err)
+	}
+	if r := rules(conform.ClientHello(&ch)); len(r) != 1 || r[conform.RuleDuplicateExtension] != 1 {
+		t.Fatal(r)
+	}
+}
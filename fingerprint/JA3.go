@@ -0,0 +1,72 @@
+package fingerprint
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+)
+
+// Fingerprint -
+type Fingerprint struct {
+	Text string
+	Hash string
+}
+
+func join(v []int) string {
+	s := make([]string, len(v))
+	for i := range v {
+		s[i] = strconv.Itoa(v[i])
+	}
+	return strings.Join(s, "-")
+}
+
+func md5hex(v string) string {
+	sum := md5.Sum([]byte(v))
+	return hex.EncodeToString(sum[:])
+}
+
+func extensionTypes(v recordfmt.HelloExtensions) (r []int) {
+	for _, w := range v {
+		r = append(r, int(w.ExtensionType))
+	}
+	return
+}
+
+// JA3 -
+func JA3(ch *recordfmt.ClientHello) Fingerprint {
+	// GREASE values are excluded from every field.
+	ch = ch.Normalize()
+
+	var suites, groups, formats []int
+	for _, v := range ch.CipherSuites {
+		suites = append(suites, int(v))
+	}
+	for _, v := range ch.SupportedGroups() {
+		groups = append(groups, int(v))
+	}
+	for _, v := range ch.ECPointFormats() {
+		formats = append(formats, int(v))
+	}
+
+	text := strings.Join([]string{
+		strconv.Itoa(int(ch.ClientVersion)),
+		join(suites),
+		join(extensionTypes(ch.Extensions)),
+		join(groups),
+		join(formats),
+	}, ",")
+	return Fingerprint{Text: text, Hash: md5hex(text)}
+}
+
+// JA3S -
+func JA3S(sh *recordfmt.ServerHello) Fingerprint {
+	text := strings.Join([]string{
+		strconv.Itoa(int(sh.ServerVersion)),
+		strconv.Itoa(int(sh.CipherSuite)),
+		join(extensionTypes(sh.Extensions)),
+	}, ",")
+	return Fingerprint{Text: text, Hash: md5hex(text)}
+}
@@ -0,0 +1,99 @@
+package fingerprint_test
+
+import (
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/fingerprint"
+	"github.com/maxbet1507/tlsaux/recordfmt"
+)
+
+func clientHello() *recordfmt.ClientHello {
+	return &recordfmt.ClientHello{
+		ClientVersion:      0x0303,
+		Random:             make(recordfmt.Random, 32),
+		CipherSuites:       recordfmt.CipherSuites{0x2a2a, 0x1301, 0xc02f},
+		CompressionMethods: recordfmt.CompressionMethods{0},
+		Extensions: recordfmt.HelloExtensions{
+			{ExtensionType: 0x1a1a, ExtensionData: []byte{}},
+			{ExtensionType: 0x0000, ExtensionData: []byte{0x00, 0x0e, 0x00, 0x00, 0x0b, 'e', 'x', 'a', 'm', 'p', 'l', 'e', '.', 'c', 'o', 'm'}},
+			{ExtensionType: recordfmt.ExtensionSupportedGroups, ExtensionData: []byte{0x00, 0x06, 0x3a, 0x3a, 0x00, 0x1d, 0x00, 0x17}},
+			{ExtensionType: recordfmt.ExtensionECPointFormats, ExtensionData: []byte{0x01, 0x00}},
+			{ExtensionType: recordfmt.ExtensionSupportedVersions, ExtensionData: []byte{0x04, 0x03, 0x04, 0x03, 0x03}},
+		},
+	}
+}
+
+func serverHello() *recordfmt.ServerHello {
+	return &recordfmt.ServerHello{
+		ServerVersion: 0x0303,
+		Random:        make(recordfmt.Random, 32),
+		CipherSuite:   0x1301,
+		Extensions: recordfmt.HelloExtensions{
+			{ExtensionType: recordfmt.ExtensionSupportedVersions, ExtensionData: []byte{0x03, 0x04}},
+			{ExtensionType: recordfmt.ExtensionKeyShare, ExtensionData: []byte{0x00, 0x1d, 0x00, 0x00}},
+		},
+	}
+}
+
+// as sent by a TLS 1.0 client, extensions and all.
+func legacyClientHello(t *testing.T) *recordfmt.ClientHello {
+	raw := []byte{
+		// client version
+		0x03, 0x01,
+		// client random
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		// session id
+		0x00,
+		// cipher suites
+		0x00, 0x08, 0xc0, 0x09, 0xc0, 0x13, 0xc0, 0x0a, 0xc0, 0x14,
+		// compression methods
+		0x01, 0x00,
+		// extensions length
+		0x00, 0x29,
+		// server_name
+		0x00, 0x00, 0x00, 0x10, 0x00, 0x0e, 0x00, 0x00, 0x0b, 'e', 'x', 'a', 'm', 'p', 'l', 'e', '.', 'c', 'o', 'm',
+		// supported_groups
+		0x00, 0x0a, 0x00, 0x06, 0x00, 0x04, 0x00, 0x17, 0x00, 0x18,
+		// ec_point_formats
+		0x00, 0x0b, 0x00, 0x02, 0x01, 0x00,
+		// renegotiation_info
+		0xff, 0x01, 0x00, 0x01, 0x00,
+	}
+
+	var v recordfmt.ClientHello
+	if err := recordfmt.Unmarshal(raw, &v, nil); err != nil {
+		t.Fatal(err)
+	}
+	return &v
+}
+
+func TestJA3(t *testing.T) {
+	v := fingerprint.JA3(clientHello())
+	if v.Text != "771,4865-49199,0-10-11-43,29-23,0" {
+		t.Fatal(v.Text)
+	}
+	if v.Hash != "cefebb3c24208325c13a9ad9b14b83c9" {
+		t.Fatal(v.Hash)
+	}
+}
+
+func TestJA3_TLS10(t *testing.T) {
+	v := fingerprint.JA3(legacyClientHello(t))
+	if v.Text != "769,49161-49171-49162-49172,0-10-11-65281,23-24,0" {
+		t.Fatal(v.Text)
+	}
+	if v.Hash != "0cd4f733bfe97a5565f715bc5869db8b" {
+		t.Fatal(v.Hash)
+	}
+}
+
+func TestJA3S(t *testing.T) {
+	v := fingerprint.JA3S(serverHello())
+	if v.Text != "771,4865,43-51" {
+		t.Fatal(v.Text)
+	}
+	if v.Hash != "f4febc55ea12b31ae17cfb7e614afda8" {
+		t.Fatal(v.Hash)
+	}
+}
@@ -0,0 +1,204 @@
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+)
+
+// Variants -
+type Variants struct {
+	Hashed      string
+	Raw         string
+	Original    string
+	OriginalRaw string
+}
+
+var (
+	ja4versions = map[recordfmt.ProtocolVersion]string{
+		0x0002:                  "s2",
+		0x0300:                  "s3",
+		0x0301:                  "10",
+		0x0302:                  "11",
+		0x0303:                  "12",
+		0x0304:                  "13",
+		recordfmt.VersionDTLS10: "d1",
+		recordfmt.VersionDTLS12: "d2",
+		0xfefc:                  "d3",
+	}
+)
+
+func ja4protocol(version recordfmt.ProtocolVersion) string {
+	if version >= 0xfe00 {
+		return "d"
+	}
+	return "t"
+}
+
+func ja4version(version recordfmt.ProtocolVersion) string {
+	if v, ok := ja4versions[version]; ok {
+		return v
+	}
+	return "00"
+}
+
+func ja4count(n int) string {
+	if n > 99 {
+		n = 99
+	}
+	return fmt.Sprintf("%02d", n)
+}
+
+func ja4alpn(v recordfmt.ProtocolNameList) string {
+	if len(v) == 0 || len(v[0]) == 0 {
+		return "00"
+	}
+
+	alnum := func(c byte) bool {
+		return '0' <= c && c <= '9' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z'
+	}
+
+	first, last := v[0][0], v[0][len(v[0])-1]
+	if !alnum(first) || !alnum(last) {
+		h := hex.EncodeToString([]byte(v[0]))
+		return h[:1] + h[len(h)-1:]
+	}
+	return string([]byte{first, last})
+}
+
+func ja4hash(v string) string {
+	if v == "" {
+		return "000000000000"
+	}
+	sum := sha256.Sum256([]byte(v))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func hex4(v []uint16) string {
+	s := make([]string, len(v))
+	for i := range v {
+		s[i] = fmt.Sprintf("%04x", v[i])
+	}
+	return strings.Join(s, ",")
+}
+
+func sorted(v []uint16) []uint16 {
+	r := append([]uint16{}, v...)
+	sort.Slice(r, func(i, j int) bool { return r[i] < r[j] })
+	return r
+}
+
+// JA4 -
+func JA4(ch *recordfmt.ClientHello) Variants {
+	ch = ch.Normalize()
+
+	// the highest offered version, DTLS versions count downwards.
+	version := ch.ClientVersion
+	for i, v := range ch.SupportedVersions() {
+		if i == 0 || (v > version) != (ja4protocol(v) == "d") {
+			version = v
+		}
+	}
+
+	sni := "i"
+	if ch.ServerName() != "" {
+		sni = "d"
+	}
+
+	var suites, exts, filtered, sigalgs []uint16
+	for _, v := range ch.CipherSuites {
+		suites = append(suites, uint16(v))
+	}
+	for _, v := range ch.Extensions {
+		exts = append(exts, uint16(v.ExtensionType))
+		if v.ExtensionType != recordfmt.ExtensionServerName && v.ExtensionType != recordfmt.ExtensionALPN {
+			filtered = append(filtered, uint16(v.ExtensionType))
+		}
+	}
+	for _, v := range ch.SignatureAlgorithms() {
+		sigalgs = append(sigalgs, uint16(v))
+	}
+
+	a := ja4protocol(ch.ClientVersion) + ja4version(version) + sni + ja4count(len(suites)) + ja4count(len(exts)) + ja4alpn(ch.ALPN())
+
+	part := func(suites, exts []uint16) (b, c string) {
+		b, c = hex4(suites), hex4(exts)
+		if len(sigalgs) > 0 && c != "" {
+			c += "_" + hex4(sigalgs)
+		}
+		return
+	}
+
+	var v Variants
+
+	// sorted lists leave out SNI and ALPN, already covered by the first part.
+	b, c := part(sorted(suites), sorted(filtered))
+	v.Hashed, v.Raw = a+"_"+ja4hash(b)+"_"+ja4hash(c), a+"_"+b+"_"+c
+
+	b, c = part(suites, exts)
+	v.Original, v.OriginalRaw = a+"_"+ja4hash(b)+"_"+ja4hash(c), a+"_"+b+"_"+c
+
+	return v
+}
+
+// JA4S -
+func JA4S(sh *recordfmt.ServerHello) Variants {
+	var exts []uint16
+	for _, v := range sh.Extensions {
+		exts = append(exts, uint16(v.ExtensionType))
+	}
+
+	a := ja4protocol(sh.ServerVersion) + ja4version(sh.SelectedVersion()) + ja4count(len(exts)) + ja4alpn(sh.ALPN())
+	b := fmt.Sprintf("%04x", uint16(sh.CipherSuite))
+	c := hex4(exts)
+
+	return Variants{
+		Hashed: a + "_" + b + "_" + ja4hash(c),
+		Raw:    a + "_" + b + "_" + c,
+	}
+}
+
+func oidhex(v asn1.ObjectIdentifier) string {
+	// hex of the encoded OID value, without tag and length.
+	var raw asn1.RawValue
+	if der, err := asn1.Marshal(v); err == nil {
+		if _, err = asn1.Unmarshal(der, &raw); err == nil {
+			return hex.EncodeToString(raw.Bytes)
+		}
+	}
+	return ""
+}
+
+func oidlist(v []asn1.ObjectIdentifier) string {
+	s := make([]string, len(v))
+	for i := range v {
+		s[i] = oidhex(v[i])
+	}
+	return strings.Join(s, ",")
+}
+
+// JA4X -
+func JA4X(cert *x509.Certificate) Variants {
+	var issuer, subject, exts []asn1.ObjectIdentifier
+	for _, v := range cert.Issuer.Names {
+		issuer = append(issuer, v.Type)
+	}
+	for _, v := range cert.Subject.Names {
+		subject = append(subject, v.Type)
+	}
+	for _, v := range cert.Extensions {
+		exts = append(exts, v.Id)
+	}
+
+	a, b, c := oidlist(issuer), oidlist(subject), oidlist(exts)
+	return Variants{
+		Hashed: ja4hash(a) + "_" + ja4hash(b) + "_" + ja4hash(c),
+		Raw:    a + "_" + b + "_" + c,
+	}
+}
@@ -0,0 +1,179 @@
+package fingerprint_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxbet1507/tlsaux/fingerprint"
+	"github.com/maxbet1507/tlsaux/recordfmt"
+	"github.com/maxbet1507/tlsaux/testcert"
+)
+
+func TestJA4(t *testing.T) {
+	ch := clientHello()
+	ch.CipherSuites = append(ch.CipherSuites, 0x002f)
+	ch.Extensions = append(ch.Extensions[:4],
+		recordfmt.HelloExtension{ExtensionType: recordfmt.ExtensionSignatureAlgorithms, ExtensionData: []byte{0x00, 0x04, 0x04, 0x03, 0x08, 0x04}},
+		recordfmt.HelloExtension{ExtensionType: recordfmt.ExtensionALPN, ExtensionData: []byte{0x00, 0x0c, 0x02, 'h', '2', 0x08, 'h', 't', 't', 'p', '/', '1', '.', '1'}},
+		recordfmt.HelloExtension{ExtensionType: recordfmt.ExtensionSupportedVersions, ExtensionData: []byte{0x06, 0x0a, 0x0a, 0x03, 0x04, 0x03, 0x03}},
+	)
+
+	v := fingerprint.JA4(ch)
+	if v.Hashed != "t13d0306h2_54093f43ad55_fb71836bce29" {
+		t.Fatal(v.Hashed)
+	}
+	if v.Raw != "t13d0306h2_002f,1301,c02f_000a,000b,000d,002b_0403,0804" {
+		t.Fatal(v.Raw)
+	}
+	if v.Original != "t13d0306h2_dc23b4e43d7e_7051468abb01" {
+		t.Fatal(v.Original)
+	}
+	if v.OriginalRaw != "t13d0306h2_1301,c02f,002f_0000,000a,000b,000d,0010,002b_0403,0804" {
+		t.Fatal(v.OriginalRaw)
+	}
+
+	// extension order does not matter to the sorted variant.
+	ch.Extensions[1], ch.Extensions[5] = ch.Extensions[5], ch.Extensions[1]
+	if w := fingerprint.JA4(ch); w.Hashed != v.Hashed || w.Original == v.Original {
+		t.Fatal(w)
+	}
+}
+
+// the Chrome hello of the FoxIO JA4 specification, which publishes
+// t13d1516h2_8daaf6152771_e5627efa2ab1 for it.
+func TestJA4_Reference(t *testing.T) {
+	ch := &recordfmt.ClientHello{
+		ClientVersion:      0x0303,
+		Random:             make(recordfmt.Random, 32),
+		CipherSuites:       recordfmt.CipherSuites{0x1301, 0x1302, 0x1303, 0xc02b, 0xc02f, 0xc02c, 0xc030, 0xcca9, 0xcca8, 0xc013, 0xc014, 0x009c, 0x009d, 0x002f, 0x0035},
+		CompressionMethods: recordfmt.CompressionMethods{0},
+	}
+	for _, v := range []uint16{0x0000, 0x0017, 0xff01, 0x000a, 0x000b, 0x0023, 0x0010, 0x0005, 0x000d, 0x0012, 0x0033, 0x002d, 0x002b, 0x001b, 0x0015, 0x4469} {
+		var data []byte
+		switch recordfmt.ExtensionType(v) {
+		case recordfmt.ExtensionServerName:
+			data = []byte{0x00, 0x0e, 0x00, 0x00, 0x0b, 'e', 'x', 'a', 'm', 'p', 'l', 'e', '.', 'c', 'o', 'm'}
+		case recordfmt.ExtensionALPN:
+			data = []byte{0x00, 0x0c, 0x02, 'h', '2', 0x08, 'h', 't', 't', 'p', '/', '1', '.', '1'}
+		case recordfmt.ExtensionSignatureAlgorithms:
+			data = []byte{0x00, 0x10, 0x04, 0x03, 0x08, 0x04, 0x04, 0x01, 0x05, 0x03, 0x08, 0x05, 0x05, 0x01, 0x08, 0x06, 0x06, 0x01}
+		case recordfmt.ExtensionSupportedVersions:
+			data = []byte{0x04, 0x03, 0x04, 0x03, 0x03}
+		}
+		ch.Extensions = append(ch.Extensions, recordfmt.HelloExtension{ExtensionType: recordfmt.ExtensionType(v), ExtensionData: data})
+	}
+
+	if v := fingerprint.JA4(ch); v.Hashed != "t13d1516h2_8daaf6152771_e5627efa2ab1" {
+		t.Fatal(v.Hashed, v.Raw)
+	}
+}
+
+func TestJA4_NoALPN(t *testing.T) {
+	ch := &recordfmt.ClientHello{ClientVersion: 0x0301}
+	if v := fingerprint.JA4(ch); v.Hashed != "t10i000000_000000000000_000000000000" {
+		t.Fatal(v.Hashed)
+	}
+}
+
+func TestJA4_TLS10(t *testing.T) {
+	v := fingerprint.JA4(legacyClientHello(t))
+	if v.Hashed != "t10d040400_cefcabfea53d_f8ec56bc740a" {
+		t.Fatal(v.Hashed)
+	}
+	if v.Raw != "t10d040400_c009,c00a,c013,c014_000a,000b,ff01" {
+		t.Fatal(v.Raw)
+	}
+}
+
+func TestJA4S(t *testing.T) {
+	v := fingerprint.JA4S(serverHello())
+	if v.Hashed != "t130200_1301_a56c5b993250" {
+		t.Fatal(v.Hashed)
+	}
+	if v.Raw != "t130200_1301_002b,0033" {
+		t.Fatal(v.Raw)
+	}
+}
+
+// a TLS 1.2 answer the FoxIO JA4S specification publishes as
+// t120400_c030_4e8089b08790.
+func TestJA4S_Reference(t *testing.T) {
+	sh := &recordfmt.ServerHello{
+		ServerVersion: 0x0303,
+		Random:        make(recordfmt.Random, 32),
+		CipherSuite:   0xc030,
+		Extensions: recordfmt.HelloExtensions{
+			{ExtensionType: 0x0005, ExtensionData: []byte{}}, // status_request
+			{ExtensionType: recordfmt.ExtensionExtendedMasterSecret, ExtensionData: []byte{}},
+			{ExtensionType: recordfmt.ExtensionRenegotiationInfo, ExtensionData: []byte{0x00}},
+			{ExtensionType: recordfmt.ExtensionServerName, ExtensionData: []byte{}},
+		},
+	}
+
+	if v := fingerprint.JA4S(sh); v.Hashed != "t120400_c030_4e8089b08790" {
+		t.Fatal(v.Hashed, v.Raw)
+	}
+}
+
+func TestJA4X(t *testing.T) {
+	raw, _, _ := testcert.SelfSigned(1024, 10*time.Second)
+	block, _ := pem.Decode(raw)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := fingerprint.JA4X(cert)
+	if !strings.HasPrefix(v.Raw, "55040a_55040a_") || !strings.Contains(v.Raw, "551d0f") {
+		t.Fatal(v.Raw)
+	}
+	if !strings.HasPrefix(v.Hashed, "b757977db3a9_b757977db3a9_") {
+		t.Fatal(v.Hashed)
+	}
+}
+
+// a self-signed certificate naming C, ST, L, O, OU and CN with only a subject
+// key identifier, which the FoxIO JA4X specification publishes as
+// 2166164053c1_2166164053c1_30d204a01551.
+func TestJA4X_Reference(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := pkix.Name{
+		Country:            []string{"US"},
+		Province:           []string{"Virginia"},
+		Locality:           []string{"Arlington"},
+		Organization:       []string{"Example"},
+		OrganizationalUnit: []string{"Research"},
+		CommonName:         "example.com",
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      name,
+		Issuer:       name,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		SubjectKeyId: []byte{0x01, 0x02, 0x03, 0x04},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := fingerprint.JA4X(cert); v.Hashed != "2166164053c1_2166164053c1_30d204a01551" {
+		t.Fatal(v.Hashed, v.Raw)
+	}
+}
@@ -0,0 +1,146 @@
+package nsskeylog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// FollowOptions -
+type FollowOptions struct {
+	Interval  time.Duration
+	FromStart bool
+}
+
+type follower struct {
+	Name    string
+	Options FollowOptions
+	Handle  func(*Entry)
+	File    *os.File
+	Info    os.FileInfo
+	Partial []byte
+	Line    int
+}
+
+func (s *follower) Open(first bool) (err error) {
+	var f *os.File
+	if f, err = os.Open(s.Name); err == nil {
+		if s.Info, err = f.Stat(); err == nil {
+			s.File, s.Partial, s.Line = f, nil, 0
+
+			// entries written before we started are history unless asked for.
+			if first && !s.Options.FromStart {
+				_, err = f.Seek(0, io.SeekEnd)
+			}
+		}
+		if err != nil {
+			f.Close()
+		}
+	}
+	return
+}
+
+func (s *follower) Close() {
+	if s.File != nil {
+		s.File.Close()
+		s.File = nil
+	}
+}
+
+func (s *follower) Offset() int64 {
+	offset, _ := s.File.Seek(0, io.SeekCurrent)
+	return offset
+}
+
+func (s *follower) Drain() (err error) {
+	var buf bytes.Buffer
+	if _, err = buf.ReadFrom(s.File); err == nil {
+		s.Partial = append(s.Partial, buf.Bytes()...)
+	}
+
+	// keep a trailing partial line until the writer finishes it.
+	if idx := bytes.LastIndexByte(s.Partial, '\n'); idx >= 0 {
+		r := NewReader(bytes.NewReader(s.Partial[:idx+1]), &ReaderOptions{SkipInvalid: true})
+		for v, err := r.Next(); err == nil; v, err = r.Next() {
+			v.Line += s.Line
+			s.Handle(v)
+		}
+		s.Line += bytes.Count(s.Partial[:idx+1], []byte{'\n'})
+		s.Partial = append([]byte{}, s.Partial[idx+1:]...)
+	}
+	return
+}
+
+func (s *follower) Poll() (err error) {
+	if s.File == nil {
+		if err = s.Open(false); os.IsNotExist(err) {
+			// rotated away and not yet recreated.
+			return nil
+		}
+	}
+	if err == nil {
+		err = s.Drain()
+	}
+
+	var info os.FileInfo
+	if err == nil {
+		switch info, err = os.Stat(s.Name); {
+		case os.IsNotExist(err):
+			s.Close()
+			err = nil
+		case err != nil:
+		case !os.SameFile(s.Info, info):
+			// rotated, the old file has been drained above.
+			s.Close()
+			if err = s.Open(false); err == nil {
+				err = s.Drain()
+			}
+		case info.Size() < s.Offset():
+			// truncated in place, start over.
+			if _, err = s.File.Seek(0, io.SeekStart); err == nil {
+				s.Partial, s.Line = nil, 0
+				err = s.Drain()
+			}
+		}
+	}
+	return
+}
+
+// Follow -
+func Follow(ctx context.Context, name string, options *FollowOptions, fn func(*Entry)) (err error) {
+	s := &follower{Name: name, Handle: fn}
+	if options != nil {
+		s.Options = *options
+	}
+	if s.Options.Interval <= 0 {
+		s.Options.Interval = time.Second
+	}
+
+	if err = s.Open(true); os.IsNotExist(err) {
+		err = nil
+	}
+	defer s.Close()
+
+	ticker := time.NewTicker(s.Options.Interval)
+	defer ticker.Stop()
+
+	for err == nil {
+		if err = s.Poll(); err == nil {
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+			case <-ticker.C:
+			}
+		}
+	}
+	return
+}
+
+// Follow -
+func (s *Store) Follow(ctx context.Context, name string, options *FollowOptions) error {
+	return Follow(ctx, name, options, func(v *Entry) {
+		s.Add(v.Label, v.Key(), v.Secret)
+	})
+}
@@ -0,0 +1,97 @@
+package nsskeylog_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxbet1507/tlsaux/nsskeylog"
+)
+
+func TestFollow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nsskeylog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "keylog.txt")
+	if err := ioutil.WriteFile(name, []byte(keylogLine("CLIENT_RANDOM", 0x01)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := nsskeylog.NewStore(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- store.Follow(ctx, name, &nsskeylog.FollowOptions{Interval: time.Millisecond})
+	}()
+
+	// poll with a deadline rather than guess how long the follower takes.
+	eventually := func(crand byte, fn func()) {
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			fn()
+			if _, ok := store.Lookup(bytes.Repeat([]byte{crand}, 32)); ok {
+				return
+			}
+		}
+		t.Fatal(crand)
+	}
+	wait := func(crand byte) {
+		eventually(crand, func() {})
+	}
+	appendFile := func(data string) {
+		f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.WriteString(data)
+		f.Close()
+	}
+	writeFile := func(data string) {
+		if err := ioutil.WriteFile(name, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// following has started once an appended line arrives.
+	eventually(0x05, func() {
+		appendFile(keylogLine("CLIENT_RANDOM", 0x05) + "\n")
+	})
+
+	// a line split across writes is delivered once complete, the marker in
+	// the same write shows the first half has been read.
+	line := keylogLine("CLIENT_RANDOM", 0x02) + "\n"
+	appendFile(keylogLine("CLIENT_RANDOM", 0x06) + "\n" + line[:20])
+	wait(0x06)
+	appendFile(line[20:])
+	wait(0x02)
+
+	// truncation, again split across writes.
+	line = keylogLine("CLIENT_RANDOM", 0x03) + "\n"
+	writeFile(keylogLine("CLIENT_RANDOM", 0x07) + "\n" + line[:10])
+	wait(0x07)
+	writeFile(keylogLine("CLIENT_RANDOM", 0x07) + "\n" + line)
+	wait(0x03)
+
+	// rotation.
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(keylogLine("CLIENT_RANDOM", 0x04) + "\n")
+	wait(0x04)
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatal(err)
+	}
+
+	// history before following started is skipped by default.
+	if _, ok := store.Lookup(bytes.Repeat([]byte{0x01}, 32)); ok {
+		t.Fatal(ok)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strings"
+	"unicode"
 
 	"github.com/pkg/errors"
 )
@@ -28,6 +29,10 @@ const (
 	ServerTrafficSecret0
 	EarlyExporterSecret
 	ExporterSecret
+	PMSClientRandom
+	RSASessionID
+	ECHSecret
+	ECHConfig
 )
 
 // -
@@ -36,7 +41,10 @@ var (
 
 	label2string      map[Label]string
 	label2decode      map[Label]func(string) ([]byte, error)
+	label2key         map[Label]func(string) ([]byte, error)
+	label2prefix      map[Label][2]string
 	string2label      map[string]Label
+	hexDecodeString8  func(string) ([]byte, error)
 	hexDecodeString32 func(string) ([]byte, error)
 	hexDecodeString48 func(string) ([]byte, error)
 )
@@ -51,6 +59,7 @@ func init() {
 			return
 		}
 	}
+	hexDecodeString8 = hexDecodeStringN(8)
 	hexDecodeString32 = hexDecodeStringN(32)
 	hexDecodeString48 = hexDecodeStringN(48)
 
@@ -65,11 +74,17 @@ func init() {
 		ServerTrafficSecret0:         "SERVER_TRAFFIC_SECRET_0",
 		EarlyExporterSecret:          "EARLY_EXPORTER_SECRET",
 		ExporterSecret:               "EXPORTER_SECRET",
+		PMSClientRandom:              "PMS_CLIENT_RANDOM",
+		RSASessionID:                 "RSA",
+		ECHSecret:                    "ECH_SECRET",
+		ECHConfig:                    "ECH_CONFIG",
 	}
 
 	label2decode = map[Label]func(string) ([]byte, error){
 		RSA:                          hexDecodeString48,
+		RSASessionID:                 hexDecodeString48,
 		ClientRandom:                 hexDecodeString48,
+		PMSClientRandom:              hexDecodeString48,
 		ClientEarlyTrafficSecret:     hex.DecodeString,
 		ServerEarlyTrafficSecret:     hex.DecodeString,
 		ClientHandshakeTrafficSecret: hex.DecodeString,
@@ -78,28 +93,77 @@ func init() {
 		ServerTrafficSecret0:         hex.DecodeString,
 		EarlyExporterSecret:          hex.DecodeString,
 		ExporterSecret:               hex.DecodeString,
+		ECHSecret:                    hex.DecodeString,
+		ECHConfig:                    hex.DecodeString,
+	}
+
+	// everything else is keyed by client random.
+	label2key = map[Label]func(string) ([]byte, error){
+		RSA: hexDecodeString8,
+		RSASessionID: func(v string) (ret []byte, err error) {
+			if ret, err = hex.DecodeString(v); err == nil {
+				err = assert(len(ret) > 0 && len(ret) <= 32, ErrInvalidFormat)
+				err = errors.Wrap(err, "Length")
+			}
+			return
+		},
+	}
+
+	// legacy Wireshark format, "RSA Session-ID:... Master-Key:...".
+	label2prefix = map[Label][2]string{
+		RSASessionID: {"Session-ID:", "Master-Key:"},
 	}
 
 	string2label = map[string]Label{}
 	for l, s := range label2string {
-		string2label[s] = l
+		if _, ok := label2prefix[l]; !ok {
+			string2label[s] = l
+		}
 	}
 }
 
+func keyDecode(label Label) func(string) ([]byte, error) {
+	if fn, ok := label2key[label]; ok {
+		return fn
+	}
+	return hexDecodeString32
+}
+
 type rawParser struct {
-	Error        error
-	Columns      []string
-	Label        Label
-	ClientRandom []byte
-	Secret       []byte
+	Error   error
+	Column  int
+	Columns []string
+	Offsets []int
+	Label   Label
+	Key     []byte
+	Secret  []byte
+}
+
+func (s *rawParser) Fail(column int, field string) {
+	if s.Error != nil {
+		// point at the first offending character of the field, if any.
+		if idx := strings.IndexFunc(field, func(c rune) bool {
+			return !strings.ContainsRune("0123456789abcdefABCDEF", c)
+		}); idx >= 0 {
+			column += idx
+		}
+		s.Column = column + 1
+	}
 }
 
 func (s *rawParser) ParseLine(v string) {
 	if s.Error == nil {
+		lead := len(v) - len(strings.TrimLeftFunc(v, unicode.IsSpace))
 		v = strings.TrimSpace(v)
 		s.Columns = strings.SplitN(v, " ", 3)
 		s.Error = assert(len(s.Columns) == 3, ErrInvalidFormat)
 		s.Error = errors.Wrap(s.Error, "Columns")
+		s.Fail(lead+len(v), "")
+
+		for i, offset := 0, lead; i < len(s.Columns); i++ {
+			s.Offsets = append(s.Offsets, offset)
+			offset += len(s.Columns[i]) + 1
+		}
 	}
 	return
 }
@@ -108,16 +172,33 @@ func (s *rawParser) ParseLabel() (err error) {
 	if s.Error == nil {
 		var ok bool
 		s.Label, ok = string2label[s.Columns[0]]
+		if s.Label == RSA && strings.HasPrefix(s.Columns[1], label2prefix[RSASessionID][0]) {
+			s.Label = RSASessionID
+		}
 		s.Error = assert(ok, ErrInvalidFormat)
 		s.Error = errors.Wrap(s.Error, "Label")
+		s.Fail(s.Offsets[0], "")
 	}
 	return
 }
 
-func (s *rawParser) ParseClientRandom() {
+func (s *rawParser) ParseColumn(idx int, fn func(string) ([]byte, error)) (r []byte) {
+	prefix := label2prefix[s.Label][idx-1]
+	field := s.Columns[idx]
+
+	if s.Error = assert(strings.HasPrefix(field, prefix), ErrInvalidFormat); s.Error == nil {
+		r, s.Error = fn(field[len(prefix):])
+		s.Fail(s.Offsets[idx]+len(prefix), field[len(prefix):])
+	} else {
+		s.Fail(s.Offsets[idx], "")
+	}
+	return
+}
+
+func (s *rawParser) ParseKey() {
 	if s.Error == nil {
-		s.ClientRandom, s.Error = hexDecodeString32(s.Columns[1])
-		s.Error = errors.Wrap(s.Error, "ClientRandom")
+		s.Key = s.ParseColumn(1, keyDecode(s.Label))
+		s.Error = errors.Wrap(s.Error, "Key")
 	}
 	return
 }
@@ -125,21 +206,35 @@ func (s *rawParser) ParseClientRandom() {
 func (s *rawParser) ParseSecret() {
 	if s.Error == nil {
 		fn := label2decode[s.Label]
-		s.Error = assert(fn != nil, ErrInvalidFormat)
-		if s.Error == nil {
-			s.Secret, s.Error = fn(s.Columns[2])
+		if s.Error = assert(fn != nil, ErrInvalidFormat); s.Error == nil {
+			s.Secret = s.ParseColumn(2, fn)
+		} else {
+			s.Fail(s.Offsets[2], "")
 		}
 		s.Error = errors.Wrap(s.Error, "Secret")
 	}
 	return
 }
 
-// Parse -
-func Parse(v string) (Label, []byte, []byte, error) {
-	var r rawParser
+func parse(v string) (r rawParser) {
 	r.ParseLine(v)
 	r.ParseLabel()
-	r.ParseClientRandom()
+	r.ParseKey()
 	r.ParseSecret()
-	return r.Label, r.ClientRandom, r.Secret, r.Error
+	return
+}
+
+// Parse -
+func Parse(v string) (Label, []byte, []byte, error) {
+	r := parse(v)
+	return r.Label, r.Key, r.Secret, r.Error
+}
+
+// ParseEntry -
+func ParseEntry(v string) (r *Entry, err error) {
+	p := parse(v)
+	if err = p.Error; err == nil {
+		r = newEntry(p.Label, p.Key, p.Secret)
+	}
+	return
 }
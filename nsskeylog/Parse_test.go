@@ -75,3 +75,50 @@ func TestParseError_SingleSpace(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestParseEntry_Legacy(t *testing.T) {
+	cases := []struct {
+		line  string
+		label nsskeylog.Label
+		key   string
+	}{
+		{"RSA Session-ID:" + strings.Repeat("01", 32) + " Master-Key:" + strings.Repeat("02", 48), nsskeylog.RSASessionID, strings.Repeat("01", 32)},
+		{"RSA 0102030405060708 " + strings.Repeat("02", 48), nsskeylog.RSA, "0102030405060708"},
+		{"PMS_CLIENT_RANDOM " + strings.Repeat("01", 32) + " " + strings.Repeat("02", 48), nsskeylog.PMSClientRandom, strings.Repeat("01", 32)},
+		{"ECH_SECRET " + strings.Repeat("01", 32) + " " + strings.Repeat("02", 32), nsskeylog.ECHSecret, strings.Repeat("01", 32)},
+		{"ECH_CONFIG " + strings.Repeat("01", 32) + " 0203", nsskeylog.ECHConfig, strings.Repeat("01", 32)},
+	}
+
+	for _, c := range cases {
+		v, err := nsskeylog.ParseEntry(c.line)
+		if err != nil || v.Label != c.label || hex.EncodeToString(v.Key()) != c.key {
+			t.Fatal(c.line, v, err)
+		}
+		if line := nsskeylog.Format(v.Label, v.Key(), v.Secret); line != c.line {
+			t.Fatal(line)
+		}
+	}
+
+	v, _ := nsskeylog.ParseEntry(cases[0].line)
+	if len(v.SessionID) != 32 || v.ClientRandom != nil || len(v.Secret) != 48 {
+		t.Fatal(v)
+	}
+	v, _ = nsskeylog.ParseEntry(cases[1].line)
+	if len(v.EncryptedPreMasterSecret) != 8 || v.ClientRandom != nil {
+		t.Fatal(v)
+	}
+}
+
+func TestParseError_Legacy(t *testing.T) {
+	lines := []string{
+		"RSA Session-ID:" + strings.Repeat("01", 32) + " " + strings.Repeat("02", 48),
+		"RSA Session-ID: Master-Key:" + strings.Repeat("02", 48),
+		"RSA " + strings.Repeat("01", 32) + " " + strings.Repeat("02", 48),
+	}
+
+	for _, line := range lines {
+		if _, err := nsskeylog.ParseEntry(line); errors.Cause(err) != nsskeylog.ErrInvalidFormat {
+			t.Fatal(line, err)
+		}
+	}
+}
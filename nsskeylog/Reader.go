@@ -0,0 +1,148 @@
+package nsskeylog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Entry -
+type Entry struct {
+	Line                     int
+	Label                    Label
+	ClientRandom             []byte
+	SessionID                []byte
+	EncryptedPreMasterSecret []byte
+	Secret                   []byte
+}
+
+func newEntry(label Label, key, secret []byte) *Entry {
+	v := &Entry{Label: label, Secret: secret}
+	switch label {
+	case RSA:
+		v.EncryptedPreMasterSecret = key
+	case RSASessionID:
+		v.SessionID = key
+	default:
+		v.ClientRandom = key
+	}
+	return v
+}
+
+// Key -
+func (s *Entry) Key() []byte {
+	switch s.Label {
+	case RSA:
+		return s.EncryptedPreMasterSecret
+	case RSASessionID:
+		return s.SessionID
+	}
+	return s.ClientRandom
+}
+
+// SyntaxError -
+type SyntaxError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (s *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", s.Line, s.Column, s.Err)
+}
+
+// Cause -
+func (s *SyntaxError) Cause() error {
+	return s.Err
+}
+
+// Unwrap -
+func (s *SyntaxError) Unwrap() error {
+	return s.Err
+}
+
+// -
+var (
+	ErrLineTooLong = fmt.Errorf("Line Too Long")
+)
+
+// real entries stay well under a kilobyte, anything past this is not one.
+const maxLineLength = 64 * 1024
+
+// ReaderOptions -
+type ReaderOptions struct {
+	SkipInvalid bool
+}
+
+// Reader -
+type Reader struct {
+	reader  *bufio.Reader
+	options ReaderOptions
+	line    int
+	skipped []*SyntaxError
+}
+
+// NewReader -
+func NewReader(r io.Reader, options *ReaderOptions) *Reader {
+	s := &Reader{reader: bufio.NewReader(r)}
+	if options != nil {
+		s.options = *options
+	}
+	return s
+}
+
+// readLine returns the next line without its line end. An overlong line is
+// read to its end and reported with ok false.
+func (s *Reader) readLine() (line []byte, ok bool, err error) {
+	ok = true
+	for more := true; more && err == nil; {
+		var v []byte
+		if v, more, err = s.reader.ReadLine(); err == nil && ok {
+			line = append(line, v...)
+			ok = len(line) <= maxLineLength
+		}
+	}
+	if err == io.EOF && (len(line) > 0 || !ok) {
+		// ReadLine reports the last line before EOF, never with it.
+		err = nil
+	}
+	return
+}
+
+// Next -
+func (s *Reader) Next() (*Entry, error) {
+	for {
+		raw, ok, err := s.readLine()
+		if err != nil {
+			return nil, err
+		}
+		s.line++
+
+		line := string(raw)
+		if aux := strings.TrimSpace(line); ok && (aux == "" || strings.HasPrefix(aux, "#")) {
+			continue
+		}
+
+		var syntax *SyntaxError
+		if !ok {
+			syntax = &SyntaxError{Line: s.line, Column: maxLineLength + 1, Err: ErrLineTooLong}
+		} else if r := parse(line); r.Error == nil {
+			v := newEntry(r.Label, r.Key, r.Secret)
+			v.Line = s.line
+			return v, nil
+		} else {
+			syntax = &SyntaxError{Line: s.line, Column: r.Column, Err: r.Error}
+		}
+
+		if !s.options.SkipInvalid {
+			return nil, syntax
+		}
+		s.skipped = append(s.skipped, syntax)
+	}
+}
+
+// Skipped -
+func (s *Reader) Skipped() []*SyntaxError {
+	return s.skipped
+}
@@ -0,0 +1,112 @@
+package nsskeylog_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/nsskeylog"
+	"github.com/pkg/errors"
+)
+
+func TestReader(t *testing.T) {
+	data := strings.Join([]string{
+		"# SSL/TLS secrets log file",
+		"",
+		"  " + keylogLine("CLIENT_RANDOM", 0x01),
+		keylogLine("EXPORTER_SECRET", 0x02),
+	}, "\r\n")
+
+	r := nsskeylog.NewReader(strings.NewReader(data), nil)
+
+	v, err := r.Next()
+	if err != nil || v.Line != 3 || v.Label != nsskeylog.ClientRandom || len(v.Secret) != 48 {
+		t.Fatal(v, err)
+	}
+	v, err = r.Next()
+	if err != nil || v.Line != 4 || v.Label != nsskeylog.ExporterSecret || len(v.Secret) != 48 {
+		t.Fatal(v, err)
+	}
+	if _, err = r.Next(); err != io.EOF {
+		t.Fatal(err)
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	cases := []struct {
+		line   string
+		column int
+	}{
+		{"CLIENT_RANDOM 0001", 19},
+		{"CLIENT_RANDOMX " + strings.Repeat("00", 32) + " 00", 1},
+		{"CLIENT_RANDOM " + strings.Repeat("00", 16) + "zz" + strings.Repeat("00", 15) + " 00", 47},
+		{"CLIENT_RANDOM " + strings.Repeat("00", 31) + " " + strings.Repeat("00", 48), 15},
+		{"CLIENT_RANDOM " + strings.Repeat("00", 32) + " 00g0", 82},
+	}
+
+	r := nsskeylog.NewReader(strings.NewReader(cases[0].line), nil)
+	if _, err := r.Next(); errors.Cause(err) != nsskeylog.ErrInvalidFormat {
+		t.Fatal(err)
+	}
+
+	for _, c := range cases {
+		r := nsskeylog.NewReader(strings.NewReader("# header\n"+c.line), nil)
+
+		_, err := r.Next()
+		v, ok := err.(*nsskeylog.SyntaxError)
+		if !ok || v.Line != 2 || v.Column != c.column {
+			t.Fatal(c.line, err)
+		}
+	}
+}
+
+func TestReader_SkipInvalid(t *testing.T) {
+	data := strings.Join([]string{
+		"garbage",
+		keylogLine("CLIENT_RANDOM", 0x01),
+		"CLIENT_RANDOM 00 00",
+	}, "\n")
+
+	r := nsskeylog.NewReader(strings.NewReader(data), &nsskeylog.ReaderOptions{SkipInvalid: true})
+
+	v, err := r.Next()
+	if err != nil || v.Line != 2 {
+		t.Fatal(v, err)
+	}
+	if _, err = r.Next(); err != io.EOF {
+		t.Fatal(err)
+	}
+	if skipped := r.Skipped(); len(skipped) != 2 || skipped[0].Line != 1 || skipped[1].Line != 3 {
+		t.Fatal(skipped)
+	}
+}
+
+func TestReader_LineTooLong(t *testing.T) {
+	data := strings.Join([]string{
+		keylogLine("CLIENT_RANDOM", 0x01),
+		"CLIENT_RANDOM " + strings.Repeat("00", 64*1024),
+		keylogLine("EXPORTER_SECRET", 0x02),
+	}, "\n")
+
+	r := nsskeylog.NewReader(strings.NewReader(data), nil)
+	if _, err := r.Next(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Next(); errors.Cause(err) != nsskeylog.ErrLineTooLong {
+		t.Fatal(err)
+	}
+
+	// skipped like any other invalid line, the reader goes on after it.
+	r = nsskeylog.NewReader(strings.NewReader(data), &nsskeylog.ReaderOptions{SkipInvalid: true})
+	for _, line := range []int{1, 3} {
+		if v, err := r.Next(); err != nil || v.Line != line {
+			t.Fatal(v, err)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatal(err)
+	}
+	if skipped := r.Skipped(); len(skipped) != 1 || skipped[0].Line != 2 {
+		t.Fatal(skipped)
+	}
+}
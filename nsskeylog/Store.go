@@ -0,0 +1,190 @@
+package nsskeylog
+
+import (
+	"container/list"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StoreOptions -
+type StoreOptions struct {
+	TTL        time.Duration
+	MaxEntries int
+	Now        func() time.Time
+}
+
+type storeEntry struct {
+	Key     string
+	Secrets map[Label][]byte
+	Updated time.Time
+}
+
+// Store -
+type Store struct {
+	locker  sync.Mutex
+	options StoreOptions
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// NewStore -
+func NewStore(options *StoreOptions) *Store {
+	s := &Store{
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+	if options != nil {
+		s.options = *options
+	}
+	if s.options.Now == nil {
+		s.options.Now = time.Now
+	}
+	return s
+}
+
+func (s *Store) evict(now time.Time) {
+	// entries are ordered by last update, the oldest at the front.
+	for e := s.order.Front(); e != nil; e = s.order.Front() {
+		v := e.Value.(*storeEntry)
+
+		expired := s.options.TTL > 0 && now.Sub(v.Updated) >= s.options.TTL
+		overflow := s.options.MaxEntries > 0 && s.order.Len() > s.options.MaxEntries
+		if !expired && !overflow {
+			break
+		}
+		s.order.Remove(e)
+		delete(s.entries, v.Key)
+	}
+}
+
+// entries for different key kinds never share an index slot.
+func storeKey(label Label, key []byte) string {
+	switch label {
+	case RSA:
+		return "r" + string(key)
+	case RSASessionID:
+		return "s" + string(key)
+	}
+	return "c" + string(key)
+}
+
+// Add -
+func (s *Store) Add(label Label, key, secret []byte) {
+	s.locker.Lock()
+	now := s.options.Now()
+	index := storeKey(label, key)
+
+	e, ok := s.entries[index]
+	if ok {
+		s.order.MoveToBack(e)
+	} else {
+		e = s.order.PushBack(&storeEntry{Key: index, Secrets: map[Label][]byte{}})
+		s.entries[index] = e
+	}
+
+	v := e.Value.(*storeEntry)
+	v.Secrets[label] = append([]byte{}, secret...)
+	v.Updated = now
+
+	s.evict(now)
+	s.locker.Unlock()
+}
+
+// AddLine -
+func (s *Store) AddLine(line string) (err error) {
+	line = strings.TrimSpace(line)
+	if line != "" && !strings.HasPrefix(line, "#") {
+		var label Label
+		var key, secret []byte
+		if label, key, secret, err = Parse(line); err == nil {
+			s.Add(label, key, secret)
+		}
+	}
+	return
+}
+
+type countingReader struct {
+	Reader io.Reader
+	N      int64
+}
+
+func (s *countingReader) Read(p []byte) (n int, err error) {
+	n, err = s.Reader.Read(p)
+	s.N += int64(n)
+	return
+}
+
+// ReadFrom -
+func (s *Store) ReadFrom(r io.Reader) (n int64, err error) {
+	counter := &countingReader{Reader: r}
+
+	// a keylog file may be shared with other tools, ignore what is not ours.
+	reader := NewReader(counter, &ReaderOptions{SkipInvalid: true})
+	for {
+		var v *Entry
+		if v, err = reader.Next(); err != nil {
+			break
+		}
+		s.Add(v.Label, v.Key(), v.Secret)
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return counter.N, err
+}
+
+// ReadFile -
+func (s *Store) ReadFile(name string) (err error) {
+	var f *os.File
+	if f, err = os.Open(name); err == nil {
+		defer f.Close()
+		_, err = s.ReadFrom(f)
+	}
+	return
+}
+
+func (s *Store) lookup(key string) (r map[Label][]byte, ok bool) {
+	s.locker.Lock()
+	s.evict(s.options.Now())
+
+	var e *list.Element
+	if e, ok = s.entries[key]; ok {
+		r = map[Label][]byte{}
+		for k, v := range e.Value.(*storeEntry).Secrets {
+			r[k] = v
+		}
+	}
+	s.locker.Unlock()
+	return
+}
+
+// Lookup -
+func (s *Store) Lookup(crand []byte) (map[Label][]byte, bool) {
+	return s.lookup(storeKey(ClientRandom, crand))
+}
+
+// LookupSessionID -
+func (s *Store) LookupSessionID(id []byte) (map[Label][]byte, bool) {
+	return s.lookup(storeKey(RSASessionID, id))
+}
+
+// LookupEncryptedPreMasterSecret -
+func (s *Store) LookupEncryptedPreMasterSecret(prefix []byte) (map[Label][]byte, bool) {
+	// only the first 8 bytes of the encrypted pre-master secret are logged.
+	if len(prefix) > 8 {
+		prefix = prefix[:8]
+	}
+	return s.lookup(storeKey(RSA, prefix))
+}
+
+// Len -
+func (s *Store) Len() (r int) {
+	s.locker.Lock()
+	s.evict(s.options.Now())
+	r = s.order.Len()
+	s.locker.Unlock()
+	return
+}
@@ -0,0 +1,132 @@
+package nsskeylog_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maxbet1507/tlsaux/nsskeylog"
+)
+
+func keylogLine(label string, crand byte) string {
+	return label + " " + strings.Repeat(string("0123456789abcdef"[crand>>4])+string("0123456789abcdef"[crand&15]), 32) + " " + strings.Repeat("ab", 48)
+}
+
+func TestStore(t *testing.T) {
+	data := strings.Join([]string{
+		"# comment",
+		"",
+		keylogLine("CLIENT_RANDOM", 0x01),
+		keylogLine("CLIENT_TRAFFIC_SECRET_0", 0x02),
+		keylogLine("SERVER_TRAFFIC_SECRET_0", 0x02),
+		"garbage",
+	}, "\r\n")
+
+	store := nsskeylog.NewStore(nil)
+	n, err := store.ReadFrom(strings.NewReader(data))
+	if err != nil || n != int64(len(data)) {
+		t.Fatal(n, err)
+	}
+	if store.Len() != 2 {
+		t.Fatal(store.Len())
+	}
+
+	secrets, ok := store.Lookup(bytes.Repeat([]byte{0x02}, 32))
+	if !ok || len(secrets) != 2 || len(secrets[nsskeylog.ClientTrafficSecret0]) != 48 {
+		t.Fatal(secrets, ok)
+	}
+	if _, ok := store.Lookup(bytes.Repeat([]byte{0x03}, 32)); ok {
+		t.Fatal(ok)
+	}
+}
+
+func TestStore_ReadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nsskeylog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "keylog.txt")
+	if err := ioutil.WriteFile(name, []byte(keylogLine("CLIENT_RANDOM", 0x01)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := nsskeylog.NewStore(nil)
+	if err := store.ReadFile(name); err != nil || store.Len() != 1 {
+		t.Fatal(err, store.Len())
+	}
+}
+
+func TestStore_Eviction(t *testing.T) {
+	now := time.Unix(0, 0)
+	store := nsskeylog.NewStore(&nsskeylog.StoreOptions{
+		TTL:        time.Minute,
+		MaxEntries: 2,
+		Now:        func() time.Time { return now },
+	})
+
+	for i := byte(1); i <= 3; i++ {
+		store.AddLine(keylogLine("CLIENT_RANDOM", i))
+		now = now.Add(time.Second)
+	}
+	if _, ok := store.Lookup(bytes.Repeat([]byte{0x01}, 32)); ok || store.Len() != 2 {
+		t.Fatal(ok, store.Len())
+	}
+
+	// an update keeps the entry alive.
+	now = now.Add(50 * time.Second)
+	store.AddLine(keylogLine("CLIENT_TRAFFIC_SECRET_0", 0x02))
+	now = now.Add(30 * time.Second)
+	if _, ok := store.Lookup(bytes.Repeat([]byte{0x02}, 32)); !ok || store.Len() != 1 {
+		t.Fatal(ok, store.Len())
+	}
+}
+
+func TestStore_Concurrent(t *testing.T) {
+	store := nsskeylog.NewStore(nil)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i byte) {
+			defer wg.Done()
+			store.AddLine(keylogLine("CLIENT_RANDOM", i))
+			store.Lookup(bytes.Repeat([]byte{i}, 32))
+		}(byte(i))
+	}
+	wg.Wait()
+
+	if store.Len() != 16 {
+		t.Fatal(store.Len())
+	}
+}
+
+func TestStore_Legacy(t *testing.T) {
+	data := strings.Join([]string{
+		"RSA Session-ID:" + strings.Repeat("01", 32) + " Master-Key:" + strings.Repeat("02", 48),
+		"RSA 0102030405060708 " + strings.Repeat("03", 48),
+		keylogLine("CLIENT_RANDOM", 0x01),
+	}, "\n")
+
+	store := nsskeylog.NewStore(nil)
+	if _, err := store.ReadFrom(strings.NewReader(data)); err != nil || store.Len() != 3 {
+		t.Fatal(err, store.Len())
+	}
+
+	// same bytes, different kind of key.
+	if v, ok := store.LookupSessionID(bytes.Repeat([]byte{0x01}, 32)); !ok || len(v) != 1 || v[nsskeylog.RSASessionID][0] != 0x02 {
+		t.Fatal(v, ok)
+	}
+	if v, ok := store.Lookup(bytes.Repeat([]byte{0x01}, 32)); !ok || len(v) != 1 || v[nsskeylog.ClientRandom] == nil {
+		t.Fatal(v, ok)
+	}
+	if v, ok := store.LookupEncryptedPreMasterSecret([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}); !ok || v[nsskeylog.RSA][0] != 0x03 {
+		t.Fatal(v, ok)
+	}
+}
@@ -0,0 +1,54 @@
+package nsskeylog
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// -
+var (
+	ErrSecretLength = fmt.Errorf("Secret Length")
+
+	// TLS 1.3 suites crypto/tls does not name.
+	suite2hashLength = map[uint16]int{
+		0x1304: 32, // TLS_AES_128_CCM_SHA256
+		0x1305: 32, // TLS_AES_128_CCM_8_SHA256
+	}
+)
+
+func hashLength(suite uint16) (int, bool) {
+	name := tls.CipherSuiteName(suite)
+	switch {
+	case strings.HasSuffix(name, "_SHA256"):
+		return 32, true
+	case strings.HasSuffix(name, "_SHA384"):
+		return 48, true
+	}
+	n, ok := suite2hashLength[suite]
+	return n, ok
+}
+
+// SecretLength -
+func SecretLength(label Label, suite uint16) (int, bool) {
+	switch label {
+	case RSA, RSASessionID, ClientRandom, PMSClientRandom:
+		// master and pre-master secrets are 48 bytes whatever the suite.
+		return 48, true
+	case ECHSecret, ECHConfig, Unknown:
+		// ECH follows the HPKE KDF of its config, not the TLS suite.
+		return 0, false
+	}
+	return hashLength(suite)
+}
+
+// ValidateSecret -
+func ValidateSecret(label Label, secret []byte, suite uint16) (err error) {
+	if n, ok := SecretLength(label, suite); ok {
+		err = assert(len(secret) == n, ErrSecretLength)
+		err = errors.Wrapf(err, "%v: %d bytes, %s expects %d", label, len(secret), tls.CipherSuiteName(suite), n)
+	}
+	return
+}
@@ -0,0 +1,37 @@
+package nsskeylog_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/nsskeylog"
+	"github.com/pkg/errors"
+)
+
+func TestValidateSecret(t *testing.T) {
+	cases := []struct {
+		label  nsskeylog.Label
+		length int
+		suite  uint16
+		valid  bool
+	}{
+		{nsskeylog.ClientTrafficSecret0, 32, 0x1301, true},
+		{nsskeylog.ClientTrafficSecret0, 48, 0x1301, false},
+		{nsskeylog.ServerHandshakeTrafficSecret, 48, 0x1302, true},
+		{nsskeylog.ServerHandshakeTrafficSecret, 32, 0x1302, false},
+		{nsskeylog.ExporterSecret, 32, 0x1303, true},
+		{nsskeylog.ClientEarlyTrafficSecret, 32, 0x1304, true},
+		{nsskeylog.ClientRandom, 48, 0x1301, true},
+		{nsskeylog.ClientRandom, 32, 0xc02f, false},
+		{nsskeylog.ECHConfig, 7, 0x1301, true},
+		{nsskeylog.ECHSecret, 32, 0x1302, true},
+		{nsskeylog.ClientTrafficSecret0, 7, 0xffff, true},
+	}
+
+	for _, c := range cases {
+		err := nsskeylog.ValidateSecret(c.label, bytes.Repeat([]byte{0x00}, c.length), c.suite)
+		if c.valid && err != nil || !c.valid && errors.Cause(err) != nsskeylog.ErrSecretLength {
+			t.Fatal(c, err)
+		}
+	}
+}
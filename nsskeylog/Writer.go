@@ -0,0 +1,41 @@
+package nsskeylog
+
+import (
+	"encoding/hex"
+	"io"
+	"sync"
+)
+
+// Format -
+func Format(label Label, key, secret []byte) string {
+	prefix := label2prefix[label]
+	return label.String() + " " + prefix[0] + hex.EncodeToString(key) + " " + prefix[1] + hex.EncodeToString(secret)
+}
+
+// Writer -
+type Writer struct {
+	locker sync.Mutex
+	w      io.Writer
+}
+
+// NewWriter -
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteLine -
+func (s *Writer) WriteLine(label Label, key, secret []byte) (err error) {
+	// refuse to emit lines that Parse would reject.
+	line := Format(label, key, secret)
+	if _, _, _, err = Parse(line); err == nil {
+		s.locker.Lock()
+		_, err = io.WriteString(s.w, line+"\n")
+		s.locker.Unlock()
+	}
+	return
+}
+
+// WriteEntry -
+func (s *Writer) WriteEntry(v *Entry) error {
+	return s.WriteLine(v.Label, v.Key(), v.Secret)
+}
@@ -0,0 +1,63 @@
+package nsskeylog_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/nsskeylog"
+	"github.com/pkg/errors"
+)
+
+func TestFormat(t *testing.T) {
+	line := keylogLine("CLIENT_TRAFFIC_SECRET_0", 0x1f)
+
+	label, crand, secret, err := nsskeylog.Parse(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := nsskeylog.Format(label, crand, secret); v != line {
+		t.Fatal(v)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := nsskeylog.NewWriter(&buf)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i byte) {
+			defer wg.Done()
+			if err := w.WriteLine(nsskeylog.ClientRandom, bytes.Repeat([]byte{i}, 32), bytes.Repeat([]byte{i}, 48)); err != nil {
+				t.Error(err)
+			}
+		}(byte(i))
+	}
+	wg.Wait()
+
+	r := nsskeylog.NewReader(strings.NewReader(buf.String()), nil)
+	n := 0
+	for v, err := r.Next(); err != io.EOF; v, err = r.Next() {
+		if err != nil || !bytes.Equal(v.ClientRandom[:1], v.Secret[:1]) {
+			t.Fatal(v, err)
+		}
+		n++
+	}
+	if n != 16 {
+		t.Fatal(n)
+	}
+}
+
+func TestWriterError_InvalidFormat(t *testing.T) {
+	var buf bytes.Buffer
+	w := nsskeylog.NewWriter(&buf)
+
+	err := w.WriteLine(nsskeylog.ClientRandom, []byte{0x00}, bytes.Repeat([]byte{0x00}, 48))
+	if errors.Cause(err) != nsskeylog.ErrInvalidFormat || buf.Len() != 0 {
+		t.Fatal(err)
+	}
+}
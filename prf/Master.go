@@ -0,0 +1,40 @@
+package prf
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/tls"
+)
+
+// SessionHash -
+func SessionHash(version int, ciphersuite uint16, transcript []byte) (r []byte) {
+	switch version {
+	case tls.VersionTLS10, tls.VersionTLS11:
+		r = append(hsum(md5.New(), transcript), hsum(sha1.New(), transcript)...)
+
+	case tls.VersionTLS12:
+		r = hsum(hash12(ciphersuite)(), transcript)
+	}
+	return
+}
+
+// MasterSecret -
+func MasterSecret(fn func(result, secret, label, seed []byte), pms, crand, srand []byte) []byte {
+	r := make([]byte, 48)
+	fn(r, pms, []byte("master secret"), append(append([]byte{}, crand...), srand...))
+	return r
+}
+
+// ExtendedMasterSecret -
+func ExtendedMasterSecret(fn func(result, secret, label, seed []byte), pms, sessionHash []byte) []byte {
+	r := make([]byte, 48)
+	fn(r, pms, []byte("extended master secret"), sessionHash)
+	return r
+}
+
+// KeyBlock -
+func KeyBlock(fn func(result, secret, label, seed []byte), master, crand, srand []byte, n int) []byte {
+	r := make([]byte, n)
+	fn(r, master, []byte("key expansion"), append(append([]byte{}, srand...), crand...))
+	return r
+}
@@ -0,0 +1,83 @@
+package prf_test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/prf"
+)
+
+func TestSessionHash(t *testing.T) {
+	transcript := []byte("TRANSCRIPT")
+
+	md5sum, sha1sum := md5.Sum(transcript), sha1.Sum(transcript)
+	sha256sum, sha384sum := sha256.Sum256(transcript), sha512.Sum384(transcript)
+
+	cases := []struct {
+		version int
+		suite   uint16
+		chk     []byte
+	}{
+		{tls.VersionTLS10, 0x002f, append(md5sum[:], sha1sum[:]...)},
+		{tls.VersionTLS11, 0x002f, append(md5sum[:], sha1sum[:]...)},
+		{tls.VersionTLS12, 0x009c, sha256sum[:]},
+		{tls.VersionTLS12, 0x009d, sha384sum[:]},
+		{tls.VersionTLS13, 0x1301, nil},
+	}
+
+	for _, c := range cases {
+		if v := prf.SessionHash(c.version, c.suite, transcript); !bytes.Equal(v, c.chk) {
+			t.Fatal(c, v)
+		}
+	}
+}
+
+func TestMasterSecret(t *testing.T) {
+	fn, _ := prf.New(tls.VersionTLS12, 0x009c)
+	pms := bytes.Repeat([]byte{0x03}, 48)
+	crand, srand := bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x02}, 32)
+
+	chk := make([]byte, 48)
+	fn(chk, pms, []byte("master secret"), append(append([]byte{}, crand...), srand...))
+	if v := prf.MasterSecret(fn, pms, crand, srand); !bytes.Equal(v, chk) {
+		t.Fatal(v, chk)
+	}
+
+	// the seed must not alias the client random.
+	if !bytes.Equal(crand, bytes.Repeat([]byte{0x01}, 32)) {
+		t.Fatal(crand)
+	}
+
+	hash := prf.SessionHash(tls.VersionTLS12, 0x009c, []byte("TRANSCRIPT"))
+	fn(chk, pms, []byte("extended master secret"), hash)
+	if v := prf.ExtendedMasterSecret(fn, pms, hash); !bytes.Equal(v, chk) {
+		t.Fatal(v, chk)
+	}
+}
+
+func TestMasterSecret_SSL30(t *testing.T) {
+	fn, _ := prf.New(tls.VersionSSL30, 0)
+	pms := bytes.Repeat([]byte{0x03}, 48)
+	crand, srand := bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x02}, 32)
+
+	master := prf.MasterSecret(fn, pms, crand, srand)
+	if v := hex.EncodeToString(master); v != "f8bd2b8450495b364fee2584142f284a31c59a918c7769c1d7fba4697d2ec156cf52c48839c049fc042a91423ad018e8" {
+		t.Fatal(v)
+	}
+
+	chk := strings.Join([]string{
+		"b880d1ccdc0c1ed0cad1249f424282aa7e872c84bdf64900d988cd11dcace3bb6db21c955611c7ffc58bab1182b47db9",
+		"2a51690ef47d75b62629653555730d9e822aa8c9418c2c06c5233e2fe4a24fbcc7aea32460366b1e4e2a338a7294b5",
+		"750ad55b5c45e33c5a",
+	}, "")
+	if v := hex.EncodeToString(prf.KeyBlock(fn, master, crand, srand, 104)); v != chk {
+		t.Fatal(v)
+	}
+}
@@ -1,13 +1,17 @@
 package prf
 
 import (
+	"bytes"
 	"crypto/hmac"
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
 	"crypto/tls"
+	"fmt"
 	"hash"
+
+	"github.com/pkg/errors"
 )
 
 func hsum(h hash.Hash, v ...[]byte) []byte {
@@ -30,6 +34,18 @@ func phash(hashfn func() hash.Hash, result, secret, seed []byte) {
 	copy(result, concat)
 }
 
+// SSL 3.0 has no labels, the seed alone tells master secret from key block.
+func prf30(result, secret, label, seed []byte) {
+	md5hash, sha1hash := md5.New(), sha1.New()
+
+	var concat []byte
+	for i := 0; len(concat) < len(result); i++ {
+		salt := bytes.Repeat([]byte{'A' + byte(i)}, i+1)
+		concat = append(concat, hsum(md5hash, secret, hsum(sha1hash, salt, secret, seed))...)
+	}
+	copy(result, concat)
+}
+
 func prf10(result, secret, label, seed []byte) {
 	s1 := secret[:(len(secret)+1)/2]
 	s2 := secret[len(secret)/2:]
@@ -53,7 +69,10 @@ func prf12(hashfn func() hash.Hash) func(result, secret, label, seed []byte) {
 	}
 }
 
+// -
 var (
+	ErrUnsupportedVersion = fmt.Errorf("Unsupported Version")
+
 	tls12hash = map[uint16]func() hash.Hash{
 		0x009D: sha512.New384, //RSA_WITH_AES_256_GCM_SHA384
 		0x009F: sha512.New384, //DHE_RSA_WITH_AES_256_GCM_SHA384
@@ -135,18 +154,43 @@ var (
 	}
 )
 
+func init() {
+	// the other suites crypto/tls implements run on the default P_SHA256.
+	for _, v := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if _, ok := tls12hash[v.ID]; !ok {
+			tls12hash[v.ID] = sha256.New
+		}
+	}
+}
+
 // New -
-func New(version int, ciphersuite uint16) (fn func(result, secret, label, seed []byte)) {
+func New(version int, ciphersuite uint16) (fn func(result, secret, label, seed []byte), err error) {
+	if v, ok := lookupPRF(version, ciphersuite); ok {
+		return v, nil
+	}
+
 	switch version {
+	case tls.VersionSSL30:
+		fn = prf30
+
 	case tls.VersionTLS10, tls.VersionTLS11:
 		fn = prf10
 
 	case tls.VersionTLS12:
-		if hashfn := tls12hash[ciphersuite]; hashfn != nil {
-			fn = prf12(hashfn)
-		} else {
-			fn = prf12(sha256.New)
-		}
+		fn = prf12(hash12(ciphersuite))
+
+	default:
+		err = errors.Wrapf(ErrUnsupportedVersion, "%#04x", version)
 	}
 	return
 }
+
+func hash12(ciphersuite uint16) func() hash.Hash {
+	if hashfn := tls12hash[ciphersuite]; hashfn != nil {
+		return hashfn
+	}
+	if hashfn, ok := lookupHash(ciphersuite); ok {
+		return hashfn
+	}
+	return sha256.New
+}
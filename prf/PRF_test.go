@@ -7,14 +7,46 @@ import (
 	"testing"
 
 	"github.com/maxbet1507/tlsaux/prf"
+	"github.com/pkg/errors"
 )
 
+func TestPRF_SSL30(t *testing.T) {
+	label := []byte("LABEL")
+	secret := []byte("SECRET")
+	seed := []byte("SEED")
+
+	prf, err := prf.New(tls.VersionSSL30, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret := make([]byte, 64)
+	prf(ret, secret, label, seed)
+
+	chk := strings.Join([]string{
+		"145706ea3f20f21195a884c341b7365184a2d418892b8228d777853dab18053f",
+		"3b34bce18ddfa3660da2b63ebb388562354d6743a1f1c32b3667a31d3751f7c1",
+	}, "")
+
+	if ret := hex.EncodeToString(ret); ret != chk {
+		t.Fatal(ret, chk)
+	}
+}
+
+func TestPRF_Unsupported(t *testing.T) {
+	for _, version := range []int{0x0002, tls.VersionTLS13} {
+		if fn, err := prf.New(version, 0); fn != nil || errors.Cause(err) != prf.ErrUnsupportedVersion {
+			t.Fatal(version, err)
+		}
+	}
+}
+
 func TestPRF_TLS10(t *testing.T) {
 	label := []byte("LABEL")
 	secret := []byte("SECRET")
 	seed := []byte("SEED")
 
-	prf := prf.New(tls.VersionTLS10, 0)
+	prf, _ := prf.New(tls.VersionTLS10, 0)
 
 	ret := make([]byte, 1024)
 	prf(ret, secret, label, seed)
@@ -48,7 +80,7 @@ func TestPRF_TLS12_SHA256(t *testing.T) {
 	secret := []byte("SECRET")
 	seed := []byte("SEED")
 
-	prf := prf.New(tls.VersionTLS12, 0x009c) // TLS_RSA_WITH_AES_128_GCM_SHA256
+	prf, _ := prf.New(tls.VersionTLS12, 0x009c) // TLS_RSA_WITH_AES_128_GCM_SHA256
 
 	ret := make([]byte, 1024)
 	prf(ret, secret, label, seed)
@@ -82,7 +114,7 @@ func TestPRF_TLS12_SHA384(t *testing.T) {
 	secret := []byte("SECRET")
 	seed := []byte("SEED")
 
-	prf := prf.New(tls.VersionTLS12, 0x009d) // TLS_RSA_WITH_AES_256_GCM_SHA384
+	prf, _ := prf.New(tls.VersionTLS12, 0x009d) // TLS_RSA_WITH_AES_256_GCM_SHA384
 
 	ret := make([]byte, 1024)
 	prf(ret, secret, label, seed)
@@ -0,0 +1,95 @@
+package prf
+
+import (
+	"crypto/tls"
+	"fmt"
+	"hash"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type registryKey struct {
+	Version     int
+	CipherSuite uint16
+}
+
+// -
+var (
+	ErrConflict = fmt.Errorf("Conflict")
+	ErrNilFunc  = fmt.Errorf("Nil Func")
+
+	registryLocker sync.RWMutex
+	registeredHash = map[uint16]func() hash.Hash{}
+	registeredPRF  = map[registryKey]func(result, secret, label, seed []byte){}
+)
+
+// suites with a hash in tls12hash are not up for grabs. A suite IANA assigns
+// but crypto/tls does not implement, such as DHE_RSA_WITH_AES_128_CBC_SHA256,
+// may still be registered.
+func builtin(version int, ciphersuite uint16) bool {
+	switch version {
+	case tls.VersionSSL30, tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12:
+		_, ok := tls12hash[ciphersuite]
+		return ok
+	}
+	return false
+}
+
+func registered(version int, ciphersuite uint16) bool {
+	_, ok := registeredPRF[registryKey{version, ciphersuite}]
+	if version == tls.VersionTLS12 {
+		_, hashed := registeredHash[ciphersuite]
+		ok = ok || hashed
+	}
+	return ok
+}
+
+// RegisterHash -
+// the hash drives the TLS 1.2 PRF and session hash of the suite. TLS 1.3
+// suites, such as the SM suites of RFC 8998, derive their secrets with HKDF
+// and are not covered by this package.
+func RegisterHash(ciphersuite uint16, hashfn func() hash.Hash) (err error) {
+	registryLocker.Lock()
+	conflict := builtin(tls.VersionTLS12, ciphersuite) || registered(tls.VersionTLS12, ciphersuite)
+	if err = assert(hashfn != nil, ErrNilFunc); err == nil {
+		err = assert(!conflict, ErrConflict)
+	}
+	if err == nil {
+		registeredHash[ciphersuite] = hashfn
+	}
+	registryLocker.Unlock()
+
+	err = errors.Wrapf(err, "RegisterHash %#04x", ciphersuite)
+	return
+}
+
+// RegisterPRF -
+func RegisterPRF(version int, ciphersuite uint16, fn func(result, secret, label, seed []byte)) (err error) {
+	registryLocker.Lock()
+	conflict := builtin(version, ciphersuite) || registered(version, ciphersuite)
+	if err = assert(fn != nil, ErrNilFunc); err == nil {
+		err = assert(!conflict, ErrConflict)
+	}
+	if err == nil {
+		registeredPRF[registryKey{version, ciphersuite}] = fn
+	}
+	registryLocker.Unlock()
+
+	err = errors.Wrapf(err, "RegisterPRF %#04x %#04x", version, ciphersuite)
+	return
+}
+
+func lookupPRF(version int, ciphersuite uint16) (fn func(result, secret, label, seed []byte), ok bool) {
+	registryLocker.RLock()
+	fn, ok = registeredPRF[registryKey{version, ciphersuite}]
+	registryLocker.RUnlock()
+	return
+}
+
+func lookupHash(ciphersuite uint16) (hashfn func() hash.Hash, ok bool) {
+	registryLocker.RLock()
+	hashfn, ok = registeredHash[ciphersuite]
+	registryLocker.RUnlock()
+	return
+}
@@ -0,0 +1,139 @@
+package prf_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/prf"
+	"github.com/pkg/errors"
+)
+
+// registration is process wide, so it happens once however often the tests run.
+var (
+	customCalled bool
+	registerErrs = []error{
+		prf.RegisterHash(0xff10, sha512.New),
+		prf.RegisterPRF(0x7f01, 0xff11, func(result, secret, label, seed []byte) { customCalled = true }),
+		prf.RegisterHash(0xff12, sha512.New),
+		prf.RegisterHash(0x0067, sha256.New), // DHE_RSA_WITH_AES_128_CBC_SHA256, not implemented by crypto/tls
+	}
+)
+
+func TestRegisterHash(t *testing.T) {
+	const suite = 0xff10 // private use
+
+	if err := registerErrs[0]; err != nil {
+		t.Fatal(err)
+	}
+	if err := prf.RegisterHash(suite, sha512.New); errors.Cause(err) != prf.ErrConflict {
+		t.Fatal(err)
+	}
+
+	fn, err := prf.New(tls.VersionTLS12, suite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// P_SHA512 fits in a single block for 64 bytes.
+	secret, label, seed := []byte("SECRET"), []byte("LABEL"), []byte("SEED")
+	mac := hmac.New(sha512.New, secret)
+	mac.Write(append(label, seed...))
+	a1 := mac.Sum(nil)
+	mac.Reset()
+	mac.Write(append(append([]byte{}, a1...), append(label, seed...)...))
+	chk := mac.Sum(nil)
+
+	ret := make([]byte, 64)
+	fn(ret, secret, label, seed)
+	if !bytes.Equal(ret, chk) {
+		t.Fatal(ret, chk)
+	}
+
+	if v := prf.SessionHash(tls.VersionTLS12, suite, nil); len(v) != sha512.Size {
+		t.Fatal(v)
+	}
+}
+
+func TestRegisterPRF(t *testing.T) {
+	const version, suite = 0x7f01, 0xff11 // private use
+
+	nop := func(result, secret, label, seed []byte) {}
+
+	if err := registerErrs[1]; err != nil {
+		t.Fatal(err)
+	}
+	if err := prf.RegisterPRF(version, suite, nop); errors.Cause(err) != prf.ErrConflict {
+		t.Fatal(err)
+	}
+
+	fn, err := prf.New(version, suite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	customCalled = false
+	if fn(nil, nil, nil, nil); !customCalled {
+		t.Fatal(customCalled)
+	}
+
+	// other suites of the same version are still unknown.
+	if _, err := prf.New(version, suite+1); errors.Cause(err) != prf.ErrUnsupportedVersion {
+		t.Fatal(err)
+	}
+}
+
+func TestRegister_Builtin(t *testing.T) {
+	nop := func(result, secret, label, seed []byte) {}
+
+	cases := []error{
+		prf.RegisterHash(0x009d, sha512.New), // TLS_RSA_WITH_AES_256_GCM_SHA384
+		prf.RegisterHash(0x009c, sha512.New), // TLS_RSA_WITH_AES_128_GCM_SHA256
+		prf.RegisterHash(0xc0b1, sha512.New), // ECCPWD_WITH_AES_256_GCM_SHA384
+		prf.RegisterPRF(tls.VersionTLS10, 0x002f, nop),
+		prf.RegisterPRF(tls.VersionTLS12, 0xc02f, nop),
+	}
+	for i, err := range cases {
+		if errors.Cause(err) != prf.ErrConflict {
+			t.Fatal(i, err)
+		}
+	}
+
+	// a hash and a whole PRF for the same TLS 1.2 suite would disagree.
+	if err := registerErrs[2]; err != nil {
+		t.Fatal(err)
+	}
+	if err := prf.RegisterPRF(tls.VersionTLS12, 0xff12, nop); errors.Cause(err) != prf.ErrConflict {
+		t.Fatal(err)
+	}
+}
+
+func TestRegister_NilFunc(t *testing.T) {
+	if err := prf.RegisterHash(0xff13, nil); errors.Cause(err) != prf.ErrNilFunc {
+		t.Fatal(err)
+	}
+	if err := prf.RegisterPRF(0x7f01, 0xff13, nil); errors.Cause(err) != prf.ErrNilFunc {
+		t.Fatal(err)
+	}
+
+	// a rejected registration leaves the suite unknown.
+	if _, err := prf.New(0x7f01, 0xff13); errors.Cause(err) != prf.ErrUnsupportedVersion {
+		t.Fatal(err)
+	}
+}
+
+func TestRegister_NotImplemented(t *testing.T) {
+	const suite = 0x0067 // DHE_RSA_WITH_AES_128_CBC_SHA256
+
+	if err := registerErrs[3]; err != nil {
+		t.Fatal(err)
+	}
+	if v := prf.SessionHash(tls.VersionTLS12, suite, nil); len(v) != sha256.Size {
+		t.Fatal(v)
+	}
+	if _, err := prf.New(tls.VersionTLS12, suite); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,8 @@
+package prf
+
+func assert(f bool, err error) error {
+	if f {
+		err = nil
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+package recordfmt
+
+import (
+	"fmt"
+	"io"
+)
+
+// AlertLevel -
+type AlertLevel uint8
+
+// -
+const (
+	AlertLevelWarning = AlertLevel(1)
+	AlertLevelFatal   = AlertLevel(2)
+)
+
+func (s AlertLevel) String() string {
+	switch s {
+	case AlertLevelWarning:
+		return "warning"
+	case AlertLevelFatal:
+		return "fatal"
+	}
+	return fmt.Sprintf("AlertLevel(%d)", uint8(s))
+}
+
+// Decode -
+func (s *AlertLevel) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *AlertLevel) parse(d *Reader) (err error) {
+	var raw uint8
+	if raw, err = d.readUint8("AlertLevel"); err == nil {
+		*s = AlertLevel(raw)
+	}
+	return
+}
+
+// AlertDescription -
+type AlertDescription uint8
+
+// -
+const (
+	AlertCloseNotify                  = AlertDescription(0)
+	AlertUnexpectedMessage            = AlertDescription(10)
+	AlertBadRecordMAC                 = AlertDescription(20)
+	AlertDecryptionFailed             = AlertDescription(21)
+	AlertRecordOverflow               = AlertDescription(22)
+	AlertDecompressionFailure         = AlertDescription(30)
+	AlertHandshakeFailure             = AlertDescription(40)
+	AlertNoCertificate                = AlertDescription(41)
+	AlertBadCertificate               = AlertDescription(42)
+	AlertUnsupportedCertificate       = AlertDescription(43)
+	AlertCertificateRevoked           = AlertDescription(44)
+	AlertCertificateExpired           = AlertDescription(45)
+	AlertCertificateUnknown           = AlertDescription(46)
+	AlertIllegalParameter             = AlertDescription(47)
+	AlertUnknownCA                    = AlertDescription(48)
+	AlertAccessDenied                 = AlertDescription(49)
+	AlertDecodeError                  = AlertDescription(50)
+	AlertDecryptError                 = AlertDescription(51)
+	AlertExportRestriction            = AlertDescription(60)
+	AlertProtocolVersion              = AlertDescription(70)
+	AlertInsufficientSecurity         = AlertDescription(71)
+	AlertInternalError                = AlertDescription(80)
+	AlertInappropriateFallback        = AlertDescription(86)
+	AlertUserCanceled                 = AlertDescription(90)
+	AlertNoRenegotiation              = AlertDescription(100)
+	AlertMissingExtension             = AlertDescription(109)
+	AlertUnsupportedExtension         = AlertDescription(110)
+	AlertCertificateUnobtainable      = AlertDescription(111)
+	AlertUnrecognizedName             = AlertDescription(112)
+	AlertBadCertificateStatusResponse = AlertDescription(113)
+	AlertBadCertificateHashValue      = AlertDescription(114)
+	AlertUnknownPSKIdentity           = AlertDescription(115)
+	AlertCertificateRequired          = AlertDescription(116)
+	AlertNoApplicationProtocol        = AlertDescription(120)
+)
+
+var (
+	alertDescription2string = map[AlertDescription]string{
+		AlertCloseNotify:                  "close_notify",
+		AlertUnexpectedMessage:            "unexpected_message",
+		AlertBadRecordMAC:                 "bad_record_mac",
+		AlertDecryptionFailed:             "decryption_failed",
+		AlertRecordOverflow:               "record_overflow",
+		AlertDecompressionFailure:         "decompression_failure",
+		AlertHandshakeFailure:             "handshake_failure",
+		AlertNoCertificate:                "no_certificate",
+		AlertBadCertificate:               "bad_certificate",
+		AlertUnsupportedCertificate:       "unsupported_certificate",
+		AlertCertificateRevoked:           "certificate_revoked",
+		AlertCertificateExpired:           "certificate_expired",
+		AlertCertificateUnknown:           "certificate_unknown",
+		AlertIllegalParameter:             "illegal_parameter",
+		AlertUnknownCA:                    "unknown_ca",
+		AlertAccessDenied:                 "access_denied",
+		AlertDecodeError:                  "decode_error",
+		AlertDecryptError:                 "decrypt_error",
+		AlertExportRestriction:            "export_restriction",
+		AlertProtocolVersion:              "protocol_version",
+		AlertInsufficientSecurity:         "insufficient_security",
+		AlertInternalError:                "internal_error",
+		AlertInappropriateFallback:        "inappropriate_fallback",
+		AlertUserCanceled:                 "user_canceled",
+		AlertNoRenegotiation:              "no_renegotiation",
+		AlertMissingExtension:             "missing_extension",
+		AlertUnsupportedExtension:         "unsupported_extension",
+		AlertCertificateUnobtainable:      "certificate_unobtainable",
+		AlertUnrecognizedName:             "unrecognized_name",
+		AlertBadCertificateStatusResponse: "bad_certificate_status_response",
+		AlertBadCertificateHashValue:      "bad_certificate_hash_value",
+		AlertUnknownPSKIdentity:           "unknown_psk_identity",
+		AlertCertificateRequired:          "certificate_required",
+		AlertNoApplicationProtocol:        "no_application_protocol",
+	}
+)
+
+func (s AlertDescription) String() string {
+	if v, ok := alertDescription2string[s]; ok {
+		return v
+	}
+	return fmt.Sprintf("AlertDescription(%d)", uint8(s))
+}
+
+// Decode -
+func (s *AlertDescription) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *AlertDescription) parse(d *Reader) (err error) {
+	var raw uint8
+	if raw, err = d.readUint8("AlertDescription"); err == nil {
+		*s = AlertDescription(raw)
+	}
+	return
+}
+
+// Alert -
+type Alert struct {
+	Level       AlertLevel
+	Description AlertDescription
+}
+
+func (s Alert) String() string {
+	return s.Level.String() + " " + s.Description.String()
+}
+
+// Decode -
+func (s *Alert) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *Alert) parse(d *Reader) (err error) {
+	var v Alert
+
+	fn := []func(*Reader) error{
+		v.Level.parse,
+		v.Description.parse,
+	}
+	for i := 0; i < len(fn) && err == nil; i++ {
+		err = fn[i](d)
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
@@ -0,0 +1,44 @@
+package recordfmt_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+)
+
+func TestAlertUnmarshal(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// level
+		0x02,
+		// description
+		0x28,
+
+		// debris
+		0x30,
+	})
+
+	var val recordfmt.Alert
+	if err := val.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if val.Level != recordfmt.AlertLevelFatal || val.Description != recordfmt.AlertHandshakeFailure {
+		t.Fatal(val)
+	}
+	if v := val.String(); v != "fatal handshake_failure" {
+		t.Fatal(v)
+	}
+
+	if v := buf.Bytes(); bytes.Compare(v, []byte{0x30}) != 0 {
+		t.Fatal(v)
+	}
+}
+
+func TestAlertString_Unknown(t *testing.T) {
+	val := recordfmt.Alert{Level: 3, Description: 255}
+
+	if v := val.String(); v != "AlertLevel(3) AlertDescription(255)" {
+		t.Fatal(v)
+	}
+}
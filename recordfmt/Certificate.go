@@ -0,0 +1,140 @@
+package recordfmt
+
+import (
+	"crypto/x509"
+	"io"
+)
+
+// CertificateList -
+type CertificateList []CertificateData
+
+// Decode -
+func (s *CertificateList) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CertificateList) parse(d *Reader) (err error) {
+	var v CertificateListView
+	if err = v.parse(d); err == nil {
+		*s = v.CertificateList()
+	}
+	return
+}
+
+// CertificateListView -
+type CertificateListView []byte
+
+// Decode -
+func (s *CertificateListView) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CertificateListView) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque24("CertificateList"); err == nil && !whole(raw, 0, 3, d.options.MaxHandshakeLength) {
+		r, limit := d.enter(raw)
+		for r.more() && err == nil {
+			var w CertificateData
+			err = w.parse(r)
+		}
+		d.leave(r, limit)
+	}
+
+	if err == nil {
+		*s = raw
+	}
+	return
+}
+
+// Next -
+func (s CertificateListView) Next() (v CertificateData, rest CertificateListView, ok bool) {
+	if len(s) >= 3 {
+		n := 3 + (int(s[0])<<16 | int(s[1])<<8 | int(s[2]))
+		if ok = n <= len(s); ok {
+			v, rest = CertificateData(s[3:n:n]), s[n:]
+		}
+	}
+	return
+}
+
+// Len -
+func (s CertificateListView) Len() (n int) {
+	for _, rest, ok := s.Next(); ok; _, rest, ok = rest.Next() {
+		n++
+	}
+	return
+}
+
+// CertificateList -
+func (s CertificateListView) CertificateList() (r CertificateList) {
+	r = make(CertificateList, 0, s.Len())
+	for v, rest, ok := s.Next(); ok; v, rest, ok = rest.Next() {
+		r = append(r, v)
+	}
+	return
+}
+
+// X509 -
+func (s CertificateList) X509() (r []*x509.Certificate, err error) {
+	for i := 0; i < len(s) && err == nil; i++ {
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(s[i]); err == nil {
+			r = append(r, cert)
+		}
+	}
+	if err != nil {
+		r = nil
+	}
+	return
+}
+
+// Certificate -
+type Certificate struct {
+	CertificateList CertificateList
+}
+
+// Decode -
+func (s *Certificate) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *Certificate) parse(d *Reader) (err error) {
+	var v CertificateView
+	if err = v.parse(d); err == nil {
+		*s = Certificate{CertificateList: v.CertificateList.CertificateList()}
+	}
+	return
+}
+
+// CertificateView -
+type CertificateView struct {
+	CertificateList CertificateListView
+}
+
+// Decode -
+func (s *CertificateView) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CertificateView) parse(d *Reader) (err error) {
+	var v CertificateView
+
+	if err = v.CertificateList.parse(d); err == nil {
+		*s = v
+	}
+	return
+}
+
+// X509 -
+func (s *Certificate) X509() ([]*x509.Certificate, error) {
+	return s.CertificateList.X509()
+}
+
+// X509 -
+func (s *Certificate13) X509() ([]*x509.Certificate, error) {
+	var list CertificateList
+	for _, v := range s.CertificateList {
+		list = append(list, v.CertData)
+	}
+	return list.X509()
+}
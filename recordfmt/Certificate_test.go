@@ -0,0 +1,120 @@
+package recordfmt_test
+
+import (
+	"bytes"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+	"github.com/maxbet1507/tlsaux/testcert"
+)
+
+func TestCertificateUnmarshal(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// certificate list length
+		0x00, 0x00, 0x09,
+		// certificate list[0] length
+		0x00, 0x00, 0x02,
+		// certificate list[0]
+		0x10, 0x11,
+		// certificate list[1] length
+		0x00, 0x00, 0x01,
+		// certificate list[1]
+		0x20,
+
+		// debris
+		0x30,
+	})
+
+	var val recordfmt.Certificate
+	if err := val.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(val.CertificateList) != 2 {
+		t.Fatal(val)
+	}
+	if bytes.Compare(val.CertificateList[0], []byte{0x10, 0x11}) != 0 {
+		t.Fatal(val)
+	}
+	if bytes.Compare(val.CertificateList[1], []byte{0x20}) != 0 {
+		t.Fatal(val)
+	}
+
+	if v := buf.Bytes(); bytes.Compare(v, []byte{0x30}) != 0 {
+		t.Fatal(v)
+	}
+}
+
+func TestCertificateListView(t *testing.T) {
+	data := []byte{
+		// certificate list length
+		0x00, 0x00, 0x09,
+		// certificate list[0] length
+		0x00, 0x00, 0x02,
+		// certificate list[0]
+		0x10, 0x11,
+		// certificate list[1] length
+		0x00, 0x00, 0x01,
+		// certificate list[1]
+		0x20,
+	}
+
+	var val recordfmt.CertificateListView
+	if err := recordfmt.Unmarshal(data, &val, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var certs []recordfmt.CertificateData
+	for v, rest, ok := val.Next(); ok; v, rest, ok = rest.Next() {
+		certs = append(certs, v)
+	}
+	if len(certs) != 2 || val.Len() != 2 {
+		t.Fatal(certs)
+	}
+	if !bytes.Equal(certs[0], []byte{0x10, 0x11}) || !bytes.Equal(certs[1], []byte{0x20}) {
+		t.Fatal(certs)
+	}
+
+	// a certificate overrunning the list is caught before anything walks it.
+	data[5] = 0x09
+	err := recordfmt.Unmarshal(data, &val, nil)
+	if v, ok := err.(*recordfmt.DecodeError); !ok || v.Type != "CertificateData" || v.Offset != 6 {
+		t.Fatal(err)
+	}
+}
+
+func TestCertificate_X509(t *testing.T) {
+	cert, _, _ := testcert.SelfSigned(1024, 10*time.Second)
+	block, _ := pem.Decode(cert)
+
+	val := recordfmt.Certificate{
+		CertificateList: recordfmt.CertificateList{block.Bytes},
+	}
+
+	certs, err := val.X509()
+	if err != nil || len(certs) != 1 {
+		t.Fatal(certs, err)
+	}
+	if certs[0].DNSNames[0] != "localhost" {
+		t.Fatal(certs[0])
+	}
+
+	val13 := recordfmt.Certificate13{
+		CertificateList: recordfmt.CertificateEntries{{CertData: block.Bytes}},
+	}
+	if certs, err := val13.X509(); err != nil || len(certs) != 1 {
+		t.Fatal(certs, err)
+	}
+}
+
+func TestCertificate_X509Error(t *testing.T) {
+	val := recordfmt.Certificate{
+		CertificateList: recordfmt.CertificateList{{0x10, 0x11}},
+	}
+
+	if certs, err := val.X509(); err == nil || certs != nil {
+		t.Fatal(certs, err)
+	}
+}
@@ -0,0 +1,293 @@
+package recordfmt
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// -
+const (
+	VersionDTLS10 = ProtocolVersion(0xfeff)
+	VersionDTLS12 = ProtocolVersion(0xfefd)
+
+	TypeHelloVerifyRequest = HandshakeType(3)
+)
+
+// DTLSPlaintext -
+type DTLSPlaintext struct {
+	Type           ContentType
+	Version        ProtocolVersion
+	Epoch          uint16
+	SequenceNumber uint64
+	Fragment       Fragment
+}
+
+// Decode -
+func (s *DTLSPlaintext) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *DTLSPlaintext) parse(d *Reader) (err error) {
+	var v DTLSPlaintext
+
+	if err = v.Type.parse(d); err == nil {
+		err = v.Version.parse(d)
+	}
+	if err == nil {
+		v.Epoch, err = d.readUint16("Epoch")
+	}
+	if err == nil {
+		var hi uint16
+		var lo uint32
+		if hi, err = d.readUint16("SequenceNumber"); err == nil {
+			if lo, err = d.readUint32("SequenceNumber"); err == nil {
+				v.SequenceNumber = uint64(hi)<<32 | uint64(lo)
+			}
+		}
+	}
+	if err == nil {
+		err = v.Fragment.parse(d)
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
+
+// DTLSHandshake -
+type DTLSHandshake struct {
+	MsgType        HandshakeType
+	Length         int
+	MessageSeq     uint16
+	FragmentOffset int
+	FragmentLength int
+	Body           HandshakeBody
+}
+
+// Decode -
+func (s *DTLSHandshake) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *DTLSHandshake) parse(d *Reader) (err error) {
+	var v DTLSHandshake
+
+	offset := d.offset
+
+	if err = v.MsgType.parse(d); err == nil {
+		v.Length, err = d.readUint24("Length")
+	}
+	if err == nil {
+		v.MessageSeq, err = d.readUint16("MessageSeq")
+	}
+	if err == nil {
+		v.FragmentOffset, err = d.readUint24("FragmentOffset")
+	}
+	if err == nil {
+		v.FragmentLength, err = d.readUint24("FragmentLength")
+	}
+	if err == nil {
+		switch {
+		case v.Length > d.options.MaxHandshakeLength:
+			err = d.fail("DTLSHandshake", offset, ErrTooLarge, fmt.Sprintf("length %d exceeds limit %d", v.Length, d.options.MaxHandshakeLength))
+		case v.FragmentOffset+v.FragmentLength > v.Length:
+			err = d.fail("DTLSHandshake", offset, ErrMalformed, "fragment exceeds message")
+		default:
+			v.Body, err = d.readOpaque("HandshakeBody", v.FragmentLength)
+		}
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
+
+// Handshake -
+func (s *DTLSHandshake) Handshake() *Handshake {
+	return &Handshake{MsgType: s.MsgType, Body: s.Body}
+}
+
+// DTLSCookie -
+type DTLSCookie []byte
+
+// Decode -
+func (s *DTLSCookie) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *DTLSCookie) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque8("DTLSCookie"); err == nil {
+		*s = raw
+	}
+	return
+}
+
+// HelloVerifyRequest -
+type HelloVerifyRequest struct {
+	ServerVersion ProtocolVersion
+	Cookie        DTLSCookie
+}
+
+// Decode -
+func (s *HelloVerifyRequest) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *HelloVerifyRequest) parse(d *Reader) (err error) {
+	var v HelloVerifyRequest
+
+	fn := []func(*Reader) error{
+		v.ServerVersion.parse,
+		v.Cookie.parse,
+	}
+	for i := 0; i < len(fn) && err == nil; i++ {
+		err = fn[i](d)
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
+
+// DTLSClientHello -
+type DTLSClientHello struct {
+	ClientVersion      ProtocolVersion
+	Random             Random
+	SessionID          SessionID
+	Cookie             DTLSCookie
+	CipherSuites       CipherSuites
+	CompressionMethods CompressionMethods
+	Extensions         HelloExtensions
+}
+
+// Decode -
+func (s *DTLSClientHello) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *DTLSClientHello) parse(d *Reader) (err error) {
+	var v DTLSClientHello
+
+	fn := []func(*Reader) error{
+		v.ClientVersion.parse,
+		v.Random.parse,
+		v.SessionID.parse,
+		v.Cookie.parse,
+		v.CipherSuites.parse,
+		v.CompressionMethods.parse,
+		v.Extensions.parse,
+	}
+	for i := 0; i < len(fn) && err == nil; i++ {
+		err = fn[i](d)
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
+
+// ClientHello -
+func (s *DTLSClientHello) ClientHello() *ClientHello {
+	return &ClientHello{
+		ClientVersion:      s.ClientVersion,
+		Random:             s.Random,
+		SessionID:          s.SessionID,
+		CipherSuites:       s.CipherSuites,
+		CompressionMethods: s.CompressionMethods,
+		Extensions:         s.Extensions,
+	}
+}
+
+const dtlsReassemblyWindow = 16
+
+type dtlsMessage struct {
+	MsgType  HandshakeType
+	Body     []byte
+	Received [][2]int
+}
+
+func (s *dtlsMessage) Add(offset int, body []byte) {
+	copy(s.Body[offset:], body)
+
+	// keep received ranges sorted and merged, retransmissions may overlap.
+	s.Received = append(s.Received, [2]int{offset, offset + len(body)})
+	sort.Slice(s.Received, func(i, j int) bool {
+		return s.Received[i][0] < s.Received[j][0]
+	})
+
+	merged := s.Received[:1]
+	for _, v := range s.Received[1:] {
+		if last := &merged[len(merged)-1]; v[0] <= last[1] {
+			if v[1] > last[1] {
+				last[1] = v[1]
+			}
+		} else {
+			merged = append(merged, v)
+		}
+	}
+	s.Received = merged
+}
+
+func (s *dtlsMessage) Complete() bool {
+	return len(s.Received) == 1 && s.Received[0][0] == 0 && s.Received[0][1] == len(s.Body)
+}
+
+// DTLSReassembler -
+type DTLSReassembler struct {
+	Options *DecodeOptions
+
+	next     uint16
+	messages map[uint16]*dtlsMessage
+}
+
+// Push -
+func (s *DTLSReassembler) Push(v *DTLSHandshake) (r []*DTLSHandshake, err error) {
+	if v.MessageSeq < s.next || v.MessageSeq-s.next >= dtlsReassemblyWindow {
+		// retransmission of a message already delivered, or too far ahead to buffer.
+		return
+	}
+
+	// the length is the peer's word, check it before buffering a message that size.
+	if limit := normalizeOptions(s.Options).MaxHandshakeLength; v.Length > limit {
+		err = &DecodeError{Type: "DTLSHandshake", Reason: fmt.Sprintf("length %d exceeds limit %d", v.Length, limit), Err: ErrTooLarge}
+		return
+	}
+	if v.FragmentOffset+v.FragmentLength > v.Length || v.FragmentOffset+len(v.Body) > v.Length {
+		err = &DecodeError{Type: "DTLSHandshake", Reason: fmt.Sprintf("fragment overruns message %d", v.MessageSeq), Err: ErrMalformed}
+		return
+	}
+
+	if s.messages == nil {
+		s.messages = map[uint16]*dtlsMessage{}
+	}
+
+	m, ok := s.messages[v.MessageSeq]
+	if !ok {
+		m = &dtlsMessage{MsgType: v.MsgType, Body: make([]byte, v.Length)}
+		s.messages[v.MessageSeq] = m
+	}
+	if m.MsgType != v.MsgType || len(m.Body) != v.Length {
+		err = &DecodeError{Type: "DTLSHandshake", Reason: fmt.Sprintf("inconsistent fragment of message %d", v.MessageSeq), Err: ErrMalformed}
+		return
+	}
+	m.Add(v.FragmentOffset, v.Body)
+
+	for m, ok = s.messages[s.next]; ok && m.Complete(); m, ok = s.messages[s.next] {
+		r = append(r, &DTLSHandshake{
+			MsgType:        m.MsgType,
+			Length:         len(m.Body),
+			MessageSeq:     s.next,
+			FragmentLength: len(m.Body),
+			Body:           m.Body,
+		})
+		delete(s.messages, s.next)
+		s.next++
+	}
+	return
+}
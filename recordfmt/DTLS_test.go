@@ -0,0 +1,203 @@
+package recordfmt_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+	"github.com/pkg/errors"
+)
+
+func TestDTLSPlaintext(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// type
+		0x16,
+		// version
+		0xfe, 0xfd,
+		// epoch
+		0x00, 0x01,
+		// sequence number
+		0x00, 0x00, 0x00, 0x00, 0x01, 0x02,
+		// fragment
+		0x00, 0x02, 0x10, 0x11,
+	})
+
+	var val recordfmt.DTLSPlaintext
+	if err := val.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+	if val.Type != recordfmt.TypeHandshake || val.Version != recordfmt.VersionDTLS12 {
+		t.Fatal(val)
+	}
+	if val.Epoch != 1 || val.SequenceNumber != 0x0102 {
+		t.Fatal(val)
+	}
+	if !bytes.Equal(val.Fragment, []byte{0x10, 0x11}) {
+		t.Fatal(val.Fragment)
+	}
+}
+
+func TestDTLSHandshake(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// msg type
+		0x03,
+		// length
+		0x00, 0x00, 0x05,
+		// message seq
+		0x00, 0x00,
+		// fragment offset
+		0x00, 0x00, 0x00,
+		// fragment length
+		0x00, 0x00, 0x05,
+		// body
+		0xfe, 0xff, 0x02, 0x20, 0x21,
+	})
+
+	var val recordfmt.DTLSHandshake
+	if err := val.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+	if val.MsgType != recordfmt.TypeHelloVerifyRequest || val.Length != 5 || val.FragmentLength != 5 {
+		t.Fatal(val)
+	}
+
+	var hvr recordfmt.HelloVerifyRequest
+	if err := recordfmt.Unmarshal(val.Body, &hvr, &recordfmt.DecodeOptions{Strict: true}); err != nil {
+		t.Fatal(err)
+	}
+	if hvr.ServerVersion != recordfmt.VersionDTLS10 || !bytes.Equal(hvr.Cookie, []byte{0x20, 0x21}) {
+		t.Fatal(hvr)
+	}
+}
+
+func TestDTLSHandshakeError_Malformed(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// msg type
+		0x01,
+		// length
+		0x00, 0x00, 0x04,
+		// message seq
+		0x00, 0x00,
+		// fragment offset
+		0x00, 0x00, 0x03,
+		// fragment length
+		0x00, 0x00, 0x02,
+		// body
+		0x10, 0x11,
+	})
+
+	var val recordfmt.DTLSHandshake
+	if err := val.Decode(buf); errors.Cause(err) != recordfmt.ErrMalformed {
+		t.Fatal(err)
+	}
+}
+
+func TestDTLSClientHello(t *testing.T) {
+	data := []byte{
+		// client version
+		0xfe, 0xfd,
+		// random
+		0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+		0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+		// session id
+		0x00,
+		// cookie
+		0x02, 0x20, 0x21,
+		// cipher suites
+		0x00, 0x02, 0xc0, 0x2b,
+		// compression methods
+		0x01, 0x00,
+	}
+
+	var val recordfmt.DTLSClientHello
+	if err := recordfmt.Unmarshal(data, &val, &recordfmt.DecodeOptions{Strict: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(val.Cookie, []byte{0x20, 0x21}) || len(val.CipherSuites) != 1 || val.CipherSuites[0] != 0xc02b {
+		t.Fatal(val)
+	}
+	if hello := val.ClientHello(); hello.ClientVersion != recordfmt.VersionDTLS12 || len(hello.Random) != 32 {
+		t.Fatal(hello)
+	}
+}
+
+func TestDTLSReassembler(t *testing.T) {
+	fragment := func(seq uint16, offset int, body string) *recordfmt.DTLSHandshake {
+		return &recordfmt.DTLSHandshake{
+			MsgType:        recordfmt.TypeCertificate,
+			Length:         6,
+			MessageSeq:     seq,
+			FragmentOffset: offset,
+			FragmentLength: len(body),
+			Body:           []byte(body),
+		}
+	}
+
+	var val recordfmt.DTLSReassembler
+
+	steps := []struct {
+		fragment *recordfmt.DTLSHandshake
+		expected []string
+	}{
+		{fragment(1, 0, "abcdef"), nil},
+		{fragment(0, 3, "def"), nil},
+		{fragment(0, 1, "bcd"), nil},
+		{fragment(0, 0, "a"), []string{"abcdef", "abcdef"}},
+		{fragment(0, 0, "abcdef"), nil},
+		{fragment(2, 0, "abc"), nil},
+		{fragment(2, 3, "def"), []string{"abcdef"}},
+	}
+
+	for i, step := range steps {
+		r, err := val.Push(step.fragment)
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if len(r) != len(step.expected) {
+			t.Fatal(i, r)
+		}
+		for j := range r {
+			if string(r[j].Body) != step.expected[j] || r[j].FragmentOffset != 0 || r[j].FragmentLength != 6 {
+				t.Fatal(i, r[j])
+			}
+		}
+	}
+
+	if _, err := val.Push(&recordfmt.DTLSHandshake{MsgType: recordfmt.TypeFinished, Length: 6, MessageSeq: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := val.Push(fragment(3, 0, "abc")); errors.Cause(err) != recordfmt.ErrMalformed {
+		t.Fatal(err)
+	}
+}
+
+func TestDTLSReassemblerError_TooLarge(t *testing.T) {
+	val := recordfmt.DTLSReassembler{Options: &recordfmt.DecodeOptions{MaxHandshakeLength: 0x100}}
+
+	_, err := val.Push(&recordfmt.DTLSHandshake{MsgType: recordfmt.TypeCertificate, Length: 0x101})
+	if errors.Cause(err) != recordfmt.ErrTooLarge {
+		t.Fatal(err)
+	}
+
+	// the defaults apply without options.
+	val = recordfmt.DTLSReassembler{}
+	_, err = val.Push(&recordfmt.DTLSHandshake{MsgType: recordfmt.TypeCertificate, Length: 0xffffff})
+	if errors.Cause(err) != recordfmt.ErrTooLarge {
+		t.Fatal(err)
+	}
+}
+
+func TestDTLSReassemblerError_Overrun(t *testing.T) {
+	var val recordfmt.DTLSReassembler
+
+	_, err := val.Push(&recordfmt.DTLSHandshake{
+		MsgType:        recordfmt.TypeCertificate,
+		Length:         6,
+		FragmentOffset: 4,
+		FragmentLength: 3,
+		Body:           []byte("abc"),
+	})
+	if errors.Cause(err) != recordfmt.ErrMalformed {
+		t.Fatal(err)
+	}
+}
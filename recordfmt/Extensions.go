@@ -0,0 +1,576 @@
+package recordfmt
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+)
+
+// -
+const (
+	ExtensionServerName           = ExtensionType(0)
+	ExtensionSupportedGroups      = ExtensionType(10)
+	ExtensionECPointFormats       = ExtensionType(11)
+	ExtensionSignatureAlgorithms  = ExtensionType(13)
+	ExtensionALPN                 = ExtensionType(16)
+	ExtensionExtendedMasterSecret = ExtensionType(23)
+	ExtensionSessionTicket        = ExtensionType(35)
+	ExtensionPreSharedKey         = ExtensionType(41)
+	ExtensionSupportedVersions    = ExtensionType(43)
+	ExtensionCookie               = ExtensionType(44)
+	ExtensionKeyShare             = ExtensionType(51)
+	ExtensionRenegotiationInfo    = ExtensionType(0xff01)
+)
+
+// Find -
+func (s HelloExtensions) Find(t ExtensionType) (ExtensionData, bool) {
+	for _, v := range s {
+		if v.ExtensionType == t {
+			return v.ExtensionData, true
+		}
+	}
+	return nil, false
+}
+
+// Find -
+func (s HelloExtensionsView) Find(t ExtensionType) (ExtensionData, bool) {
+	for v, rest, ok := s.Next(); ok; v, rest, ok = rest.Next() {
+		if v.ExtensionType == t {
+			return v.ExtensionData, true
+		}
+	}
+	return nil, false
+}
+
+// ServerNameList -
+type ServerNameList []ServerName
+
+// ServerName -
+type ServerName struct {
+	NameType uint8
+	HostName []byte
+}
+
+// Decode -
+func (s *ServerNameList) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *ServerNameList) parse(d *Reader) (err error) {
+	var v ServerNameListView
+	if err = v.parse(d); err == nil {
+		var r ServerNameList
+		for w, rest, ok := v.Next(); ok; w, rest, ok = rest.Next() {
+			r = append(r, w)
+		}
+		*s = r
+	}
+	return
+}
+
+// ServerNameListView -
+type ServerNameListView []byte
+
+// Decode -
+func (s *ServerNameListView) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *ServerNameListView) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque16("ServerNameList"); err == nil && !whole(raw, 1, 2, d.options.MaxVectorLength) {
+		r, limit := d.enter(raw)
+		for r.more() && err == nil {
+			if _, err = r.readUint8("NameType"); err == nil {
+				_, err = r.readOpaque16("HostName")
+			}
+		}
+		d.leave(r, limit)
+	}
+
+	if err == nil {
+		*s = raw
+	}
+	return
+}
+
+// Next -
+func (s ServerNameListView) Next() (v ServerName, rest ServerNameListView, ok bool) {
+	if len(s) >= 3 {
+		n := 3 + int(binary.BigEndian.Uint16(s[1:]))
+		if ok = n <= len(s); ok {
+			v, rest = ServerName{NameType: s[0], HostName: s[3:n:n]}, s[n:]
+		}
+	}
+	return
+}
+
+// ServerName -
+func (s *ClientHello) ServerName() string {
+	if data, ok := s.Extensions.Find(ExtensionServerName); ok {
+		var v ServerNameListView
+		if err := Unmarshal(data, &v, nil); err == nil {
+			for w, rest, ok := v.Next(); ok; w, rest, ok = rest.Next() {
+				if w.NameType == 0 {
+					return string(w.HostName)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// ProtocolNameList -
+type ProtocolNameList []string
+
+// Decode -
+func (s *ProtocolNameList) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *ProtocolNameList) parse(d *Reader) (err error) {
+	var v ProtocolNameListView
+	if err = v.parse(d); err == nil {
+		var r ProtocolNameList
+		for w, rest, ok := v.Next(); ok; w, rest, ok = rest.Next() {
+			r = append(r, string(w))
+		}
+		*s = r
+	}
+	return
+}
+
+// ProtocolNameListView -
+type ProtocolNameListView []byte
+
+// Decode -
+func (s *ProtocolNameListView) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *ProtocolNameListView) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque16("ProtocolNameList"); err == nil && !whole(raw, 0, 1, d.options.MaxVectorLength) {
+		r, limit := d.enter(raw)
+		for r.more() && err == nil {
+			_, err = r.readOpaque8("ProtocolName")
+		}
+		d.leave(r, limit)
+	}
+
+	if err == nil {
+		*s = raw
+	}
+	return
+}
+
+// Next -
+func (s ProtocolNameListView) Next() (v []byte, rest ProtocolNameListView, ok bool) {
+	if len(s) >= 1 {
+		n := 1 + int(s[0])
+		if ok = n <= len(s); ok {
+			v, rest = s[1:n:n], s[n:]
+		}
+	}
+	return
+}
+
+func findALPN(exts HelloExtensions) ProtocolNameList {
+	if data, ok := exts.Find(ExtensionALPN); ok {
+		var v ProtocolNameList
+		if err := Unmarshal(data, &v, nil); err == nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// ALPN -
+func (s *ClientHello) ALPN() ProtocolNameList {
+	return findALPN(s.Extensions)
+}
+
+// ALPN -
+func (s *ServerHello) ALPN() ProtocolNameList {
+	return findALPN(s.Extensions)
+}
+
+// SignatureSchemeList -
+type SignatureSchemeList []SignatureScheme
+
+// Decode -
+func (s *SignatureSchemeList) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *SignatureSchemeList) parse(d *Reader) (err error) {
+	var v SignatureSchemeListView
+	if err = v.parse(d); err == nil {
+		r := make(SignatureSchemeList, v.Len())
+		for i := range r {
+			r[i] = v.At(i)
+		}
+		*s = r
+	}
+	return
+}
+
+// SignatureSchemeListView -
+type SignatureSchemeListView []byte
+
+// Decode -
+func (s *SignatureSchemeListView) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *SignatureSchemeListView) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque16("SignatureSchemeList"); err == nil {
+		if err = d.elements("SignatureScheme", raw, 2); err == nil {
+			*s = raw
+		}
+	}
+	return
+}
+
+// Len -
+func (s SignatureSchemeListView) Len() int {
+	return len(s) / 2
+}
+
+// At -
+func (s SignatureSchemeListView) At(i int) SignatureScheme {
+	return SignatureScheme(binary.BigEndian.Uint16(s[2*i:]))
+}
+
+// SignatureAlgorithms -
+func (s *ClientHello) SignatureAlgorithms() SignatureSchemeList {
+	if data, ok := s.Extensions.Find(ExtensionSignatureAlgorithms); ok {
+		var v SignatureSchemeList
+		if err := Unmarshal(data, &v, nil); err == nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// SupportedVersions -
+type SupportedVersions []ProtocolVersion
+
+// Decode -
+func (s *SupportedVersions) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *SupportedVersions) parse(d *Reader) (err error) {
+	var v SupportedVersionsView
+	if err = v.parse(d); err == nil {
+		r := make(SupportedVersions, v.Len())
+		for i := range r {
+			r[i] = v.At(i)
+		}
+		*s = r
+	}
+	return
+}
+
+// SupportedVersionsView -
+type SupportedVersionsView []byte
+
+// Decode -
+func (s *SupportedVersionsView) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *SupportedVersionsView) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque8("SupportedVersions"); err == nil {
+		if err = d.elements("ProtocolVersion", raw, 2); err == nil {
+			*s = raw
+		}
+	}
+	return
+}
+
+// Len -
+func (s SupportedVersionsView) Len() int {
+	return len(s) / 2
+}
+
+// At -
+func (s SupportedVersionsView) At(i int) ProtocolVersion {
+	return ProtocolVersion(binary.BigEndian.Uint16(s[2*i:]))
+}
+
+// SupportedVersions -
+func (s *ClientHello) SupportedVersions() SupportedVersions {
+	if data, ok := s.Extensions.Find(ExtensionSupportedVersions); ok {
+		var v SupportedVersions
+		if err := Unmarshal(data, &v, nil); err == nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// NamedGroup -
+type NamedGroup uint16
+
+// Decode -
+func (s *NamedGroup) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *NamedGroup) parse(d *Reader) (err error) {
+	var raw uint16
+	if raw, err = d.readUint16("NamedGroup"); err == nil {
+		*s = NamedGroup(raw)
+	}
+	return
+}
+
+// SupportedGroups -
+type SupportedGroups []NamedGroup
+
+// Decode -
+func (s *SupportedGroups) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *SupportedGroups) parse(d *Reader) (err error) {
+	var v SupportedGroupsView
+	if err = v.parse(d); err == nil {
+		r := make(SupportedGroups, v.Len())
+		for i := range r {
+			r[i] = v.At(i)
+		}
+		*s = r
+	}
+	return
+}
+
+// SupportedGroupsView -
+type SupportedGroupsView []byte
+
+// Decode -
+func (s *SupportedGroupsView) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *SupportedGroupsView) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque16("SupportedGroups"); err == nil {
+		if err = d.elements("NamedGroup", raw, 2); err == nil {
+			*s = raw
+		}
+	}
+	return
+}
+
+// Len -
+func (s SupportedGroupsView) Len() int {
+	return len(s) / 2
+}
+
+// At -
+func (s SupportedGroupsView) At(i int) NamedGroup {
+	return NamedGroup(binary.BigEndian.Uint16(s[2*i:]))
+}
+
+// SupportedGroups -
+func (s *ClientHello) SupportedGroups() SupportedGroups {
+	if data, ok := s.Extensions.Find(ExtensionSupportedGroups); ok {
+		var v SupportedGroups
+		if err := Unmarshal(data, &v, nil); err == nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// ECPointFormats -
+type ECPointFormats []uint8
+
+// Decode -
+func (s *ECPointFormats) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *ECPointFormats) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque8("ECPointFormats"); err == nil {
+		*s = raw
+	}
+	return
+}
+
+// ECPointFormats -
+func (s *ClientHello) ECPointFormats() ECPointFormats {
+	if data, ok := s.Extensions.Find(ExtensionECPointFormats); ok {
+		var v ECPointFormats
+		if err := Unmarshal(data, &v, nil); err == nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// KeyShareEntry -
+type KeyShareEntry struct {
+	Group       NamedGroup
+	KeyExchange []byte
+}
+
+// Decode -
+func (s *KeyShareEntry) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *KeyShareEntry) parse(d *Reader) (err error) {
+	var v KeyShareEntry
+
+	if err = v.Group.parse(d); err == nil {
+		v.KeyExchange, err = d.readOpaque16("KeyExchange")
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
+
+// KeyShareEntries -
+type KeyShareEntries []KeyShareEntry
+
+// Decode -
+func (s *KeyShareEntries) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *KeyShareEntries) parse(d *Reader) (err error) {
+	var v KeyShareEntriesView
+	if err = v.parse(d); err == nil {
+		var r KeyShareEntries
+		for w, rest, ok := v.Next(); ok; w, rest, ok = rest.Next() {
+			r = append(r, w)
+		}
+		*s = r
+	}
+	return
+}
+
+// KeyShareEntriesView -
+type KeyShareEntriesView []byte
+
+// Decode -
+func (s *KeyShareEntriesView) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *KeyShareEntriesView) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque16("KeyShareEntries"); err == nil && !whole(raw, 2, 2, d.options.MaxVectorLength) {
+		r, limit := d.enter(raw)
+		for r.more() && err == nil {
+			var w KeyShareEntry
+			err = w.parse(r)
+		}
+		d.leave(r, limit)
+	}
+
+	if err == nil {
+		*s = raw
+	}
+	return
+}
+
+// Next -
+func (s KeyShareEntriesView) Next() (v KeyShareEntry, rest KeyShareEntriesView, ok bool) {
+	if len(s) >= 4 {
+		n := 4 + int(binary.BigEndian.Uint16(s[2:]))
+		if ok = n <= len(s); ok {
+			v = KeyShareEntry{Group: NamedGroup(binary.BigEndian.Uint16(s)), KeyExchange: s[4:n:n]}
+			rest = s[n:]
+		}
+	}
+	return
+}
+
+// KeyShares -
+func (s *ClientHello) KeyShares() KeyShareEntries {
+	if data, ok := s.Extensions.Find(ExtensionKeyShare); ok {
+		var v KeyShareEntries
+		if err := Unmarshal(data, &v, nil); err == nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// Cookie -
+type Cookie []byte
+
+// Decode -
+func (s *Cookie) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *Cookie) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque16("Cookie"); err == nil {
+		*s = raw
+	}
+	return
+}
+
+// -
+var (
+	HelloRetryRequestRandom = Random{
+		0xcf, 0x21, 0xad, 0x74, 0xe5, 0x9a, 0x61, 0x11, 0xbe, 0x1d, 0x8c, 0x02, 0x1e, 0x65, 0xb8, 0x91,
+		0xc2, 0xa2, 0x11, 0x16, 0x7a, 0xbb, 0x8c, 0x5e, 0x07, 0x9e, 0x09, 0xe2, 0xc8, 0xa8, 0x33, 0x9c,
+	}
+)
+
+// IsHelloRetryRequest -
+func (s *ServerHello) IsHelloRetryRequest() bool {
+	return bytes.Equal(s.Random, HelloRetryRequestRandom)
+}
+
+// SelectedVersion -
+func (s *ServerHello) SelectedVersion() ProtocolVersion {
+	if data, ok := s.Extensions.Find(ExtensionSupportedVersions); ok {
+		var v ProtocolVersion
+		if err := Unmarshal(data, &v, nil); err == nil {
+			return v
+		}
+	}
+	return s.ServerVersion
+}
+
+// SelectedGroup -
+func (s *ServerHello) SelectedGroup() (NamedGroup, bool) {
+	if data, ok := s.Extensions.Find(ExtensionKeyShare); ok {
+		var v NamedGroup
+		if err := Unmarshal(data, &v, nil); err == nil {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// Cookie -
+func (s *ServerHello) Cookie() Cookie {
+	if data, ok := s.Extensions.Find(ExtensionCookie); ok {
+		var v Cookie
+		if err := Unmarshal(data, &v, nil); err == nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// IsTLS13 -
+func (s *ServerHello) IsTLS13() bool {
+	return s.SelectedVersion() >= tls.VersionTLS13
+}
@@ -0,0 +1,138 @@
+package recordfmt_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+)
+
+func TestServerHello_HelloRetryRequest(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// server version
+		0x03, 0x03,
+		// server random
+		0xcf, 0x21, 0xad, 0x74, 0xe5, 0x9a, 0x61, 0x11, 0xbe, 0x1d, 0x8c, 0x02, 0x1e, 0x65, 0xb8, 0x91,
+		0xc2, 0xa2, 0x11, 0x16, 0x7a, 0xbb, 0x8c, 0x5e, 0x07, 0x9e, 0x09, 0xe2, 0xc8, 0xa8, 0x33, 0x9c,
+		// session id length
+		0x00,
+		// cipher suite
+		0x13, 0x01,
+		// compression method
+		0x00,
+
+		// extensions length
+		0x00, 0x15,
+		// extension[0] type (supported_versions)
+		0x00, 0x2b,
+		// extension[0] length
+		0x00, 0x02,
+		// extension[0] data
+		0x03, 0x04,
+		// extension[1] type (key_share)
+		0x00, 0x33,
+		// extension[1] length
+		0x00, 0x02,
+		// extension[1] data
+		0x00, 0x17,
+		// extension[2] type (cookie)
+		0x00, 0x2c,
+		// extension[2] length
+		0x00, 0x05,
+		// extension[2] data
+		0x00, 0x03, 0x10, 0x11, 0x12,
+	})
+
+	var val recordfmt.ServerHello
+	if err := val.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !val.IsHelloRetryRequest() {
+		t.Fatal(val)
+	}
+	if v := val.SelectedVersion(); v != 0x0304 {
+		t.Fatal(v)
+	}
+	if v, ok := val.SelectedGroup(); !ok || v != 0x0017 {
+		t.Fatal(v, ok)
+	}
+	if v := val.Cookie(); bytes.Compare(v, []byte{0x10, 0x11, 0x12}) != 0 {
+		t.Fatal(v)
+	}
+}
+
+func TestServerHello_NotHelloRetryRequest(t *testing.T) {
+	val := recordfmt.ServerHello{
+		ServerVersion: 0x0303,
+		Random:        make(recordfmt.Random, 32),
+	}
+
+	if val.IsHelloRetryRequest() {
+		t.Fatal(val)
+	}
+	if v := val.SelectedVersion(); v != 0x0303 {
+		t.Fatal(v)
+	}
+	if v, ok := val.SelectedGroup(); ok {
+		t.Fatal(v, ok)
+	}
+	if v := val.Cookie(); v != nil {
+		t.Fatal(v)
+	}
+}
+
+func TestServerNameListView(t *testing.T) {
+	data := []byte{
+		// server name list length
+		0x00, 0x0e,
+		// server name[0] type
+		0x00,
+		// server name[0] length
+		0x00, 0x0b,
+		// server name[0]
+		'e', 'x', 'a', 'm', 'p', 'l', 'e', '.', 'c', 'o', 'm',
+	}
+
+	var val recordfmt.ServerNameListView
+	if err := recordfmt.Unmarshal(data, &val, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	v, rest, ok := val.Next()
+	if !ok || v.NameType != 0 || string(v.HostName) != "example.com" {
+		t.Fatal(v, ok)
+	}
+	if _, _, ok = rest.Next(); ok {
+		t.Fatal(rest)
+	}
+
+	// the host name is a view into data.
+	data[5] = 'E'
+	if v.HostName[0] != 'E' {
+		t.Fatal(v)
+	}
+}
+
+func TestSupportedGroupsView(t *testing.T) {
+	data := []byte{
+		// named group list length
+		0x00, 0x04,
+		// named groups
+		0x00, 0x1d, 0x00, 0x17,
+	}
+
+	var val recordfmt.SupportedGroupsView
+	if err := recordfmt.Unmarshal(data, &val, nil); err != nil {
+		t.Fatal(err)
+	}
+	if val.Len() != 2 || val.At(0) != 0x001d || val.At(1) != 0x0017 {
+		t.Fatal(val)
+	}
+
+	data[1] = 0x03
+	err := recordfmt.Unmarshal(data[:5], &val, nil)
+	if v, ok := err.(*recordfmt.DecodeError); !ok || v.Type != "NamedGroup" || v.Offset != 4 {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,108 @@
+package recordfmt
+
+// IsGREASE -
+func IsGREASE(v uint16) bool {
+	// RFC 8701 reserves 0x0a0a, 0x1a1a, ... 0xfafa.
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+func putUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func putOpaque(b []byte, size int, v []byte) []byte {
+	for i := size - 1; i >= 0; i-- {
+		b = append(b, byte(len(v)>>(8*uint(i))))
+	}
+	return append(b, v...)
+}
+
+func (s SupportedVersions) encode() []byte {
+	var raw []byte
+	for _, v := range s {
+		raw = putUint16(raw, uint16(v))
+	}
+	return putOpaque(nil, 1, raw)
+}
+
+func (s SupportedGroups) encode() []byte {
+	var raw []byte
+	for _, v := range s {
+		raw = putUint16(raw, uint16(v))
+	}
+	return putOpaque(nil, 2, raw)
+}
+
+func (s KeyShareEntries) encode() []byte {
+	var raw []byte
+	for _, v := range s {
+		raw = putOpaque(putUint16(raw, uint16(v.Group)), 2, v.KeyExchange)
+	}
+	return putOpaque(nil, 2, raw)
+}
+
+func (s SupportedVersions) withoutGREASE() (r SupportedVersions) {
+	for _, v := range s {
+		if !IsGREASE(uint16(v)) {
+			r = append(r, v)
+		}
+	}
+	return
+}
+
+func (s SupportedGroups) withoutGREASE() (r SupportedGroups) {
+	for _, v := range s {
+		if !IsGREASE(uint16(v)) {
+			r = append(r, v)
+		}
+	}
+	return
+}
+
+func (s KeyShareEntries) withoutGREASE() (r KeyShareEntries) {
+	for _, v := range s {
+		if !IsGREASE(uint16(v.Group)) {
+			r = append(r, v)
+		}
+	}
+	return
+}
+
+// Normalize -
+func (s *ClientHello) Normalize() *ClientHello {
+	v := *s
+	v.CipherSuites, v.Extensions = nil, nil
+
+	for _, w := range s.CipherSuites {
+		if !IsGREASE(uint16(w)) {
+			v.CipherSuites = append(v.CipherSuites, w)
+		}
+	}
+
+	for _, w := range s.Extensions {
+		if IsGREASE(uint16(w.ExtensionType)) {
+			continue
+		}
+
+		// lists inside these extensions carry GREASE too, rebuild them without it.
+		switch w.ExtensionType {
+		case ExtensionSupportedVersions:
+			var list SupportedVersions
+			if err := Unmarshal(w.ExtensionData, &list, nil); err == nil {
+				w.ExtensionData = list.withoutGREASE().encode()
+			}
+		case ExtensionSupportedGroups:
+			var list SupportedGroups
+			if err := Unmarshal(w.ExtensionData, &list, nil); err == nil {
+				w.ExtensionData = list.withoutGREASE().encode()
+			}
+		case ExtensionKeyShare:
+			var list KeyShareEntries
+			if err := Unmarshal(w.ExtensionData, &list, nil); err == nil {
+				w.ExtensionData = list.withoutGREASE().encode()
+			}
+		}
+		v.Extensions = append(v.Extensions, w)
+	}
+	return &v
+}
@@ -0,0 +1,58 @@
+package recordfmt_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+)
+
+func TestIsGREASE(t *testing.T) {
+	for i := 0; i < 16; i++ {
+		v := uint16(i<<12 | 0x0a00 | i<<4 | 0x0a)
+		if !recordfmt.IsGREASE(v) {
+			t.Fatalf("%#04x", v)
+		}
+	}
+	for _, v := range []uint16{0x0a1a, 0x1301, 0x0000, 0xabab, 0x0a0b} {
+		if recordfmt.IsGREASE(v) {
+			t.Fatalf("%#04x", v)
+		}
+	}
+}
+
+func TestClientHello_Normalize(t *testing.T) {
+	val := &recordfmt.ClientHello{
+		ClientVersion: 0x0303,
+		CipherSuites:  recordfmt.CipherSuites{0x3a3a, 0x1301, 0xc02f},
+		Extensions: recordfmt.HelloExtensions{
+			{ExtensionType: 0x4a4a, ExtensionData: []byte{}},
+			{ExtensionType: recordfmt.ExtensionSupportedGroups, ExtensionData: []byte{0x00, 0x06, 0x5a, 0x5a, 0x00, 0x1d, 0x00, 0x17}},
+			{ExtensionType: recordfmt.ExtensionSupportedVersions, ExtensionData: []byte{0x06, 0x6a, 0x6a, 0x03, 0x04, 0x03, 0x03}},
+			{ExtensionType: recordfmt.ExtensionKeyShare, ExtensionData: []byte{0x00, 0x0a, 0x7a, 0x7a, 0x00, 0x01, 0x00, 0x00, 0x1d, 0x00, 0x01, 0x10}},
+			{ExtensionType: 0x8a8a, ExtensionData: []byte{0x00}},
+		},
+	}
+
+	norm := val.Normalize()
+	if len(norm.CipherSuites) != 2 || norm.CipherSuites[0] != 0x1301 {
+		t.Fatal(norm.CipherSuites)
+	}
+	if len(norm.Extensions) != 3 {
+		t.Fatal(norm.Extensions)
+	}
+	if v := norm.SupportedGroups(); len(v) != 2 || v[0] != 0x001d || v[1] != 0x0017 {
+		t.Fatal(v)
+	}
+	if v := norm.SupportedVersions(); len(v) != 2 || v[0] != 0x0304 || v[1] != 0x0303 {
+		t.Fatal(v)
+	}
+	if v := norm.KeyShares(); len(v) != 1 || v[0].Group != 0x001d || !bytes.Equal(v[0].KeyExchange, []byte{0x10}) {
+		t.Fatal(v)
+	}
+
+	// the original is left untouched.
+	if len(val.CipherSuites) != 3 || len(val.Extensions) != 5 || len(val.SupportedGroups()) != 3 {
+		t.Fatal(val)
+	}
+}
@@ -1,8 +1,6 @@
 package recordfmt
 
 import (
-	"bytes"
-	"crypto/tls"
 	"encoding/binary"
 	"io"
 )
@@ -12,22 +10,32 @@ type HandshakeType int
 
 // -
 const (
-	TypeHelloRequest       = HandshakeType(0)
-	TypeClientHello        = HandshakeType(1)
-	TypeServerHello        = HandshakeType(2)
-	TypeCertificate        = HandshakeType(11)
-	TypeServerKeyExchange  = HandshakeType(12)
-	TypeCertificateRequest = HandshakeType(13)
-	TypeServerHelloDone    = HandshakeType(14)
-	TypeCertificateVerify  = HandshakeType(15)
-	TypeClientKeyExchange  = HandshakeType(16)
-	TypeFinished           = HandshakeType(20)
+	TypeHelloRequest          = HandshakeType(0)
+	TypeClientHello           = HandshakeType(1)
+	TypeServerHello           = HandshakeType(2)
+	TypeNewSessionTicket      = HandshakeType(4)
+	TypeEndOfEarlyData        = HandshakeType(5)
+	TypeEncryptedExtensions   = HandshakeType(8)
+	TypeCertificate           = HandshakeType(11)
+	TypeServerKeyExchange     = HandshakeType(12)
+	TypeCertificateRequest    = HandshakeType(13)
+	TypeServerHelloDone       = HandshakeType(14)
+	TypeCertificateVerify     = HandshakeType(15)
+	TypeClientKeyExchange     = HandshakeType(16)
+	TypeFinished              = HandshakeType(20)
+	TypeKeyUpdate             = HandshakeType(24)
+	TypeCompressedCertificate = HandshakeType(25)
+	TypeMessageHash           = HandshakeType(254)
 )
 
 // Decode -
-func (s *HandshakeType) Decode(r io.Reader) (err error) {
+func (s *HandshakeType) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *HandshakeType) parse(d *Reader) (err error) {
 	var raw uint8
-	if err = binary.Read(r, binary.BigEndian, &raw); err == nil {
+	if raw, err = d.readUint8("HandshakeType"); err == nil {
 		*s = HandshakeType(raw)
 	}
 	return
@@ -37,13 +45,14 @@ func (s *HandshakeType) Decode(r io.Reader) (err error) {
 type HandshakeBody []byte
 
 // Decode -
-func (s *HandshakeBody) Decode(r io.Reader) (err error) {
-	aux := make([]byte, 3)
-	if err = binary.Read(r, binary.BigEndian, &aux); err == nil {
-		raw := make([]byte, (int(aux[0])<<16)+(int(aux[1])<<8)+int(aux[2]))
-		if err = binary.Read(r, binary.BigEndian, &raw); err == nil {
-			*s = raw
-		}
+func (s *HandshakeBody) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *HandshakeBody) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readVector("HandshakeBody", 3, d.options.MaxHandshakeLength); err == nil {
+		*s = raw
 	}
 	return
 }
@@ -55,15 +64,15 @@ type Handshake struct {
 }
 
 // Decode -
-func (s *Handshake) Decode(r io.Reader) (err error) {
+func (s *Handshake) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *Handshake) parse(d *Reader) (err error) {
 	var v Handshake
 
-	fn := []func(io.Reader) error{
-		v.MsgType.Decode,
-		v.Body.Decode,
-	}
-	for i := 0; i < len(fn) && err == nil; i++ {
-		err = fn[i](r)
+	if err = v.MsgType.parse(d); err == nil {
+		err = v.Body.parse(d)
 	}
 
 	if err == nil {
@@ -76,9 +85,13 @@ func (s *Handshake) Decode(r io.Reader) (err error) {
 type ExtensionType uint16
 
 // Decode -
-func (s *ExtensionType) Decode(r io.Reader) (err error) {
+func (s *ExtensionType) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *ExtensionType) parse(d *Reader) (err error) {
 	var raw uint16
-	if err = binary.Read(r, binary.BigEndian, &raw); err == nil {
+	if raw, err = d.readUint16("ExtensionType"); err == nil {
 		*s = ExtensionType(raw)
 	}
 	return
@@ -88,13 +101,14 @@ func (s *ExtensionType) Decode(r io.Reader) (err error) {
 type ExtensionData []byte
 
 // Decode -
-func (s *ExtensionData) Decode(r io.Reader) (err error) {
-	var aux uint16
-	if err = binary.Read(r, binary.BigEndian, &aux); err == nil {
-		raw := make([]byte, aux)
-		if err = binary.Read(r, binary.BigEndian, &raw); err == nil {
-			*s = raw
-		}
+func (s *ExtensionData) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *ExtensionData) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque16("ExtensionData"); err == nil {
+		*s = raw
 	}
 	return
 }
@@ -106,15 +120,17 @@ type HelloExtension struct {
 }
 
 // Decode -
-func (s *HelloExtension) Decode(r io.Reader) (err error) {
+func (s *HelloExtension) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *HelloExtension) parse(d *Reader) (err error) {
 	var v HelloExtension
 
-	fn := []func(io.Reader) error{
-		v.ExtensionType.Decode,
-		v.ExtensionData.Decode,
-	}
-	for i := 0; i < len(fn) && err == nil; i++ {
-		err = fn[i](r)
+	// called once per extension, so spelled out rather than through method
+	// values that would move v to the heap.
+	if err = v.ExtensionType.parse(d); err == nil {
+		err = v.ExtensionData.parse(d)
 	}
 
 	if err == nil {
@@ -127,27 +143,75 @@ func (s *HelloExtension) Decode(r io.Reader) (err error) {
 type HelloExtensions []HelloExtension
 
 // Decode -
-func (s *HelloExtensions) Decode(r io.Reader) (err error) {
-	var v HelloExtensions
-
-	var aux uint16
-	switch err = binary.Read(r, binary.BigEndian, &aux); err {
-	case nil:
-		raw := make([]byte, aux)
-		if err = binary.Read(r, binary.BigEndian, &raw); err == nil {
-			for r := bytes.NewBuffer(raw); r.Len() > 0 && err == nil; {
-				var w HelloExtension
-				if err = w.Decode(r); err == nil {
-					v = append(v, w)
-				}
-			}
+func (s *HelloExtensions) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *HelloExtensions) parse(d *Reader) (err error) {
+	var v HelloExtensionsView
+	if err = v.parse(d); err == nil {
+		*s = v.HelloExtensions()
+	}
+	return
+}
+
+// HelloExtensionsView -
+type HelloExtensionsView []byte
+
+// Decode -
+func (s *HelloExtensionsView) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *HelloExtensionsView) parse(d *Reader) (err error) {
+	var raw []byte
+	switch raw, err = d.readOpaque16("HelloExtensions"); {
+	case err == nil && !whole(raw, 2, 2, d.options.MaxVectorLength):
+		// walked only to report where it breaks, the view never fails later.
+		r, limit := d.enter(raw)
+		for r.more() && err == nil {
+			var w HelloExtension
+			err = w.parse(r)
 		}
-	case io.EOF:
+		d.leave(r, limit)
+	case isEOF(err):
+		// extensions are optional at the end of a hello.
 		err = nil
 	}
 
 	if err == nil {
-		*s = v
+		*s = raw
+	}
+	return
+}
+
+// Next -
+func (s HelloExtensionsView) Next() (v HelloExtension, rest HelloExtensionsView, ok bool) {
+	if len(s) >= 4 {
+		n := 4 + int(binary.BigEndian.Uint16(s[2:]))
+		if ok = n <= len(s); ok {
+			v = HelloExtension{ExtensionType(binary.BigEndian.Uint16(s)), ExtensionData(s[4:n:n])}
+			rest = s[n:]
+		}
+	}
+	return
+}
+
+// Len -
+func (s HelloExtensionsView) Len() (n int) {
+	for _, rest, ok := s.Next(); ok; _, rest, ok = rest.Next() {
+		n++
+	}
+	return
+}
+
+// HelloExtensions -
+func (s HelloExtensionsView) HelloExtensions() (r HelloExtensions) {
+	if s != nil {
+		r = make(HelloExtensions, 0, s.Len())
+		for v, rest, ok := s.Next(); ok; v, rest, ok = rest.Next() {
+			r = append(r, v)
+		}
 	}
 	return
 }
@@ -156,9 +220,13 @@ func (s *HelloExtensions) Decode(r io.Reader) (err error) {
 type Random []byte
 
 // Decode -
-func (s *Random) Decode(r io.Reader) (err error) {
-	raw := make([]byte, 32)
-	if err = binary.Read(r, binary.BigEndian, &raw); err == nil {
+func (s *Random) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *Random) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque("Random", 32); err == nil {
 		*s = raw
 	}
 	return
@@ -168,13 +236,14 @@ func (s *Random) Decode(r io.Reader) (err error) {
 type SessionID []byte
 
 // Decode -
-func (s *SessionID) Decode(r io.Reader) (err error) {
-	var aux uint8
-	if err = binary.Read(r, binary.BigEndian, &aux); err == nil {
-		raw := make([]byte, aux)
-		if err = binary.Read(r, binary.BigEndian, &raw); err == nil {
-			*s = raw
-		}
+func (s *SessionID) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *SessionID) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque8("SessionID"); err == nil {
+		*s = raw
 	}
 	return
 }
@@ -183,9 +252,13 @@ func (s *SessionID) Decode(r io.Reader) (err error) {
 type CipherSuite uint16
 
 // Decode -
-func (s *CipherSuite) Decode(r io.Reader) (err error) {
+func (s *CipherSuite) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CipherSuite) parse(d *Reader) (err error) {
 	var raw uint16
-	if err = binary.Read(r, binary.BigEndian, &raw); err == nil {
+	if raw, err = d.readUint16("CipherSuite"); err == nil {
 		*s = CipherSuite(raw)
 	}
 	return
@@ -195,35 +268,66 @@ func (s *CipherSuite) Decode(r io.Reader) (err error) {
 type CipherSuites []CipherSuite
 
 // Decode -
-func (s *CipherSuites) Decode(r io.Reader) (err error) {
-	var v CipherSuites
-
-	var aux uint16
-	if err = binary.Read(r, binary.BigEndian, &aux); err == nil {
-		raw := make([]byte, aux)
-		if err = binary.Read(r, binary.BigEndian, &raw); err == nil {
-			for r := bytes.NewBuffer(raw); r.Len() > 0 && err == nil; {
-				var w CipherSuite
-				if err = w.Decode(r); err == nil {
-					v = append(v, w)
-				}
-			}
+func (s *CipherSuites) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CipherSuites) parse(d *Reader) (err error) {
+	var v CipherSuitesView
+	if err = v.parse(d); err == nil {
+		*s = v.CipherSuites()
+	}
+	return
+}
+
+// CipherSuitesView -
+type CipherSuitesView []byte
+
+// Decode -
+func (s *CipherSuitesView) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CipherSuitesView) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque16("CipherSuites"); err == nil {
+		if err = d.elements("CipherSuite", raw, 2); err == nil {
+			*s = raw
 		}
 	}
+	return
+}
 
-	if err == nil {
-		*s = v
+// Len -
+func (s CipherSuitesView) Len() int {
+	return len(s) / 2
+}
+
+// At -
+func (s CipherSuitesView) At(i int) CipherSuite {
+	return CipherSuite(binary.BigEndian.Uint16(s[2*i:]))
+}
+
+// CipherSuites -
+func (s CipherSuitesView) CipherSuites() CipherSuites {
+	r := make(CipherSuites, s.Len())
+	for i := range r {
+		r[i] = s.At(i)
 	}
-	return nil
+	return r
 }
 
 // CompressionMethod -
 type CompressionMethod uint8
 
 // Decode -
-func (s *CompressionMethod) Decode(r io.Reader) (err error) {
+func (s *CompressionMethod) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CompressionMethod) parse(d *Reader) (err error) {
 	var raw uint8
-	if err = binary.Read(r, binary.BigEndian, &raw); err == nil {
+	if raw, err = d.readUint8("CompressionMethod"); err == nil {
 		*s = CompressionMethod(raw)
 	}
 	return
@@ -233,28 +337,53 @@ func (s *CompressionMethod) Decode(r io.Reader) (err error) {
 type CompressionMethods []CompressionMethod
 
 // Decode -
-func (s *CompressionMethods) Decode(r io.Reader) (err error) {
-	var v CompressionMethods
-
-	var aux uint8
-	if err = binary.Read(r, binary.BigEndian, &aux); err == nil {
-		raw := make([]byte, aux)
-		if err = binary.Read(r, binary.BigEndian, &raw); err == nil {
-			for r := bytes.NewBuffer(raw); r.Len() > 0 && err == nil; {
-				var w CompressionMethod
-				if err = w.Decode(r); err == nil {
-					v = append(v, w)
-				}
-			}
-		}
+func (s *CompressionMethods) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CompressionMethods) parse(d *Reader) (err error) {
+	var v CompressionMethodsView
+	if err = v.parse(d); err == nil {
+		*s = v.CompressionMethods()
 	}
+	return
+}
 
-	if err == nil {
-		*s = v
+// CompressionMethodsView -
+type CompressionMethodsView []byte
+
+// Decode -
+func (s *CompressionMethodsView) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CompressionMethodsView) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque8("CompressionMethods"); err == nil {
+		*s = raw
 	}
 	return
 }
 
+// Len -
+func (s CompressionMethodsView) Len() int {
+	return len(s)
+}
+
+// At -
+func (s CompressionMethodsView) At(i int) CompressionMethod {
+	return CompressionMethod(s[i])
+}
+
+// CompressionMethods -
+func (s CompressionMethodsView) CompressionMethods() CompressionMethods {
+	r := make(CompressionMethods, s.Len())
+	for i := range r {
+		r[i] = s.At(i)
+	}
+	return r
+}
+
 // ClientHello -
 type ClientHello struct {
 	ClientVersion      ProtocolVersion
@@ -266,21 +395,52 @@ type ClientHello struct {
 }
 
 // Decode -
-func (s *ClientHello) Decode(r io.Reader) (err error) {
-	var v ClientHello
+func (s *ClientHello) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *ClientHello) parse(d *Reader) (err error) {
+	var v ClientHelloView
+	if err = v.parse(d); err == nil {
+		*s = *v.ClientHello()
+	}
+	return
+}
+
+// ClientHelloView -
+type ClientHelloView struct {
+	ClientVersion      ProtocolVersion
+	Random             Random
+	SessionID          SessionID
+	CipherSuites       CipherSuitesView
+	CompressionMethods CompressionMethodsView
+	Extensions         HelloExtensionsView
+}
+
+// Decode -
+func (s *ClientHelloView) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
 
-	fn := []func(io.Reader) error{
-		v.ClientVersion.Decode,
-		v.Random.Decode,
-		v.SessionID.Decode,
-		v.CipherSuites.Decode,
-		v.CompressionMethods.Decode,
+func (s *ClientHelloView) parse(d *Reader) (err error) {
+	var v ClientHelloView
+
+	// spelled out, method values would move v to the heap on every hello.
+	if err = v.ClientVersion.parse(d); err == nil {
+		err = v.Random.parse(d)
+	}
+	if err == nil {
+		err = v.SessionID.parse(d)
+	}
+	if err == nil {
+		err = v.CipherSuites.parse(d)
 	}
-	for i := 0; i < len(fn) && err == nil; i++ {
-		err = fn[i](r)
+	if err == nil {
+		err = v.CompressionMethods.parse(d)
 	}
-	if err == nil && v.ClientVersion >= tls.VersionTLS12 {
-		err = v.Extensions.Decode(r)
+	if err == nil {
+		// any version may carry extensions, they are simply absent when nothing is left.
+		err = v.Extensions.parse(d)
 	}
 
 	if err == nil {
@@ -289,6 +449,18 @@ func (s *ClientHello) Decode(r io.Reader) (err error) {
 	return
 }
 
+// ClientHello -
+func (s *ClientHelloView) ClientHello() *ClientHello {
+	return &ClientHello{
+		ClientVersion:      s.ClientVersion,
+		Random:             s.Random,
+		SessionID:          s.SessionID,
+		CipherSuites:       s.CipherSuites.CipherSuites(),
+		CompressionMethods: s.CompressionMethods.CompressionMethods(),
+		Extensions:         s.Extensions.HelloExtensions(),
+	}
+}
+
 // ServerHello -
 type ServerHello struct {
 	ServerVersion     ProtocolVersion
@@ -300,21 +472,51 @@ type ServerHello struct {
 }
 
 // Decode -
-func (s *ServerHello) Decode(r io.Reader) (err error) {
-	var v ServerHello
+func (s *ServerHello) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
 
-	fn := []func(io.Reader) error{
-		v.ServerVersion.Decode,
-		v.Random.Decode,
-		v.SessionID.Decode,
-		v.CipherSuite.Decode,
-		v.CompressionMethod.Decode,
+func (s *ServerHello) parse(d *Reader) (err error) {
+	var v ServerHelloView
+	if err = v.parse(d); err == nil {
+		*s = *v.ServerHello()
 	}
-	for i := 0; i < len(fn) && err == nil; i++ {
-		err = fn[i](r)
+	return
+}
+
+// ServerHelloView -
+type ServerHelloView struct {
+	ServerVersion     ProtocolVersion
+	Random            Random
+	SessionID         SessionID
+	CipherSuite       CipherSuite
+	CompressionMethod CompressionMethod
+	Extensions        HelloExtensionsView
+}
+
+// Decode -
+func (s *ServerHelloView) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *ServerHelloView) parse(d *Reader) (err error) {
+	var v ServerHelloView
+
+	if err = v.ServerVersion.parse(d); err == nil {
+		err = v.Random.parse(d)
+	}
+	if err == nil {
+		err = v.SessionID.parse(d)
 	}
-	if err == nil && v.ServerVersion >= tls.VersionTLS12 {
-		err = v.Extensions.Decode(r)
+	if err == nil {
+		err = v.CipherSuite.parse(d)
+	}
+	if err == nil {
+		err = v.CompressionMethod.parse(d)
+	}
+	if err == nil {
+		// any version may carry extensions, they are simply absent when nothing is left.
+		err = v.Extensions.parse(d)
 	}
 
 	if err == nil {
@@ -322,3 +524,15 @@ func (s *ServerHello) Decode(r io.Reader) (err error) {
 	}
 	return
 }
+
+// ServerHello -
+func (s *ServerHelloView) ServerHello() *ServerHello {
+	return &ServerHello{
+		ServerVersion:     s.ServerVersion,
+		Random:            s.Random,
+		SessionID:         s.SessionID,
+		CipherSuite:       s.CipherSuite,
+		CompressionMethod: s.CompressionMethod,
+		Extensions:        s.Extensions.HelloExtensions(),
+	}
+}
@@ -0,0 +1,405 @@
+package recordfmt
+
+import (
+	"io"
+)
+
+func uint32Decoder(typ string, v *uint32) func(*Reader) error {
+	return func(d *Reader) (err error) {
+		*v, err = d.readUint32(typ)
+		return
+	}
+}
+
+// only the hellos may omit their extensions, every TLS 1.3 message after them
+// carries the length even when empty.
+func parseExtensions13(v *HelloExtensions) func(*Reader) error {
+	return func(d *Reader) (err error) {
+		offset := d.offset
+		if err = v.parse(d); err == nil && d.offset == offset {
+			err = d.fail("HelloExtensions", offset, ErrMalformed, "missing extensions")
+		}
+		return
+	}
+}
+
+// EncryptedExtensions -
+type EncryptedExtensions struct {
+	Extensions HelloExtensions
+}
+
+// Decode -
+func (s *EncryptedExtensions) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *EncryptedExtensions) parse(d *Reader) (err error) {
+	var v EncryptedExtensions
+
+	if err = parseExtensions13(&v.Extensions)(d); err == nil {
+		*s = v
+	}
+	return
+}
+
+// TicketNonce -
+type TicketNonce []byte
+
+// Decode -
+func (s *TicketNonce) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *TicketNonce) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque8("TicketNonce"); err == nil {
+		*s = raw
+	}
+	return
+}
+
+// SessionTicket -
+type SessionTicket []byte
+
+// Decode -
+func (s *SessionTicket) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *SessionTicket) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque16("SessionTicket"); err == nil {
+		*s = raw
+	}
+	return
+}
+
+// NewSessionTicket -
+type NewSessionTicket struct {
+	TicketLifetime uint32
+	TicketAgeAdd   uint32
+	TicketNonce    TicketNonce
+	Ticket         SessionTicket
+	Extensions     HelloExtensions
+}
+
+// Decode -
+func (s *NewSessionTicket) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *NewSessionTicket) parse(d *Reader) (err error) {
+	var v NewSessionTicket
+
+	fn := []func(*Reader) error{
+		uint32Decoder("TicketLifetime", &v.TicketLifetime),
+		uint32Decoder("TicketAgeAdd", &v.TicketAgeAdd),
+		v.TicketNonce.parse,
+		v.Ticket.parse,
+		parseExtensions13(&v.Extensions),
+	}
+	for i := 0; i < len(fn) && err == nil; i++ {
+		err = fn[i](d)
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
+
+// EndOfEarlyData -
+type EndOfEarlyData struct{}
+
+// Decode -
+func (s *EndOfEarlyData) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *EndOfEarlyData) parse(d *Reader) (err error) {
+	return
+}
+
+// KeyUpdateRequest -
+type KeyUpdateRequest uint8
+
+// -
+const (
+	UpdateNotRequested = KeyUpdateRequest(0)
+	UpdateRequested    = KeyUpdateRequest(1)
+)
+
+// Decode -
+func (s *KeyUpdateRequest) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *KeyUpdateRequest) parse(d *Reader) (err error) {
+	var raw uint8
+	if raw, err = d.readUint8("KeyUpdateRequest"); err == nil {
+		*s = KeyUpdateRequest(raw)
+	}
+	return
+}
+
+// KeyUpdate -
+type KeyUpdate struct {
+	RequestUpdate KeyUpdateRequest
+}
+
+// Decode -
+func (s *KeyUpdate) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *KeyUpdate) parse(d *Reader) (err error) {
+	var v KeyUpdate
+
+	if err = v.RequestUpdate.parse(d); err == nil {
+		*s = v
+	}
+	return
+}
+
+// CertificateCompressionAlgorithm -
+type CertificateCompressionAlgorithm uint16
+
+// -
+const (
+	CompressionZlib   = CertificateCompressionAlgorithm(1)
+	CompressionBrotli = CertificateCompressionAlgorithm(2)
+	CompressionZstd   = CertificateCompressionAlgorithm(3)
+)
+
+// Decode -
+func (s *CertificateCompressionAlgorithm) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CertificateCompressionAlgorithm) parse(d *Reader) (err error) {
+	var raw uint16
+	if raw, err = d.readUint16("CertificateCompressionAlgorithm"); err == nil {
+		*s = CertificateCompressionAlgorithm(raw)
+	}
+	return
+}
+
+// CompressedCertificate -
+type CompressedCertificate struct {
+	Algorithm                    CertificateCompressionAlgorithm
+	UncompressedLength           int
+	CompressedCertificateMessage []byte
+}
+
+// Decode -
+func (s *CompressedCertificate) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CompressedCertificate) parse(d *Reader) (err error) {
+	var v CompressedCertificate
+
+	if err = v.Algorithm.parse(d); err == nil {
+		if v.UncompressedLength, err = d.readUint24("UncompressedLength"); err == nil {
+			v.CompressedCertificateMessage, err = d.readOpaque24("CompressedCertificateMessage")
+		}
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
+
+// MessageHash -
+type MessageHash []byte
+
+// Decode -
+func (s *MessageHash) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *MessageHash) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readRest("MessageHash"); err == nil {
+		*s = raw
+	}
+	return
+}
+
+// CertificateRequestContext -
+type CertificateRequestContext []byte
+
+// Decode -
+func (s *CertificateRequestContext) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CertificateRequestContext) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque8("CertificateRequestContext"); err == nil {
+		*s = raw
+	}
+	return
+}
+
+// CertificateData -
+type CertificateData []byte
+
+// Decode -
+func (s *CertificateData) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CertificateData) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque24("CertificateData"); err == nil {
+		*s = raw
+	}
+	return
+}
+
+// CertificateEntry -
+type CertificateEntry struct {
+	CertData   CertificateData
+	Extensions HelloExtensions
+}
+
+// Decode -
+func (s *CertificateEntry) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CertificateEntry) parse(d *Reader) (err error) {
+	var v CertificateEntry
+
+	fn := []func(*Reader) error{
+		v.CertData.parse,
+		parseExtensions13(&v.Extensions),
+	}
+	for i := 0; i < len(fn) && err == nil; i++ {
+		err = fn[i](d)
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
+
+// CertificateEntries -
+type CertificateEntries []CertificateEntry
+
+// Decode -
+func (s *CertificateEntries) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CertificateEntries) parse(d *Reader) (err error) {
+	var v CertificateEntries
+
+	var raw []byte
+	if raw, err = d.readOpaque24("CertificateEntries"); err == nil {
+		r, limit := d.enter(raw)
+		for r.more() && err == nil {
+			var w CertificateEntry
+			if err = w.parse(r); err == nil {
+				v = append(v, w)
+			}
+		}
+		d.leave(r, limit)
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
+
+// Certificate13 -
+type Certificate13 struct {
+	CertificateRequestContext CertificateRequestContext
+	CertificateList           CertificateEntries
+}
+
+// Decode -
+func (s *Certificate13) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *Certificate13) parse(d *Reader) (err error) {
+	var v Certificate13
+
+	fn := []func(*Reader) error{
+		v.CertificateRequestContext.parse,
+		v.CertificateList.parse,
+	}
+	for i := 0; i < len(fn) && err == nil; i++ {
+		err = fn[i](d)
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
+
+// SignatureScheme -
+type SignatureScheme uint16
+
+// Decode -
+func (s *SignatureScheme) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *SignatureScheme) parse(d *Reader) (err error) {
+	var raw uint16
+	if raw, err = d.readUint16("SignatureScheme"); err == nil {
+		*s = SignatureScheme(raw)
+	}
+	return
+}
+
+// Signature -
+type Signature []byte
+
+// Decode -
+func (s *Signature) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *Signature) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readOpaque16("Signature"); err == nil {
+		*s = raw
+	}
+	return
+}
+
+// CertificateVerify -
+type CertificateVerify struct {
+	Algorithm SignatureScheme
+	Signature Signature
+}
+
+// Decode -
+func (s *CertificateVerify) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *CertificateVerify) parse(d *Reader) (err error) {
+	var v CertificateVerify
+
+	fn := []func(*Reader) error{
+		v.Algorithm.parse,
+		v.Signature.parse,
+	}
+	for i := 0; i < len(fn) && err == nil; i++ {
+		err = fn[i](d)
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
@@ -0,0 +1,291 @@
+package recordfmt_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+	"github.com/pkg/errors"
+)
+
+func TestEncryptedExtensionsUnmarshal(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// extensions length
+		0x00, 0x06,
+		// extension[0] type
+		0x00, 0x10,
+		// extension[0] length
+		0x00, 0x02,
+		// extension[0] data
+		0x20, 0x21,
+
+		// debris
+		0x30,
+	})
+
+	var val recordfmt.EncryptedExtensions
+	if err := val.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(val.Extensions) != 1 || val.Extensions[0].ExtensionType != 0x0010 {
+		t.Fatal(val)
+	}
+	if bytes.Compare(val.Extensions[0].ExtensionData, []byte{0x20, 0x21}) != 0 {
+		t.Fatal(val)
+	}
+
+	if v := buf.Bytes(); bytes.Compare(v, []byte{0x30}) != 0 {
+		t.Fatal(v)
+	}
+}
+
+func TestNewSessionTicketUnmarshal(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// ticket lifetime
+		0x00, 0x01, 0x02, 0x03,
+		// ticket age add
+		0x10, 0x11, 0x12, 0x13,
+		// ticket nonce length
+		0x02,
+		// ticket nonce
+		0x20, 0x21,
+		// ticket length
+		0x00, 0x03,
+		// ticket
+		0x30, 0x31, 0x32,
+		// extensions length
+		0x00, 0x04,
+		// extension[0] type
+		0x00, 0x2a,
+		// extension[0] length
+		0x00, 0x00,
+
+		// debris
+		0x40,
+	})
+
+	var val recordfmt.NewSessionTicket
+	if err := val.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if val.TicketLifetime != 0x00010203 || val.TicketAgeAdd != 0x10111213 {
+		t.Fatal(val)
+	}
+	if bytes.Compare(val.TicketNonce, []byte{0x20, 0x21}) != 0 {
+		t.Fatal(val)
+	}
+	if bytes.Compare(val.Ticket, []byte{0x30, 0x31, 0x32}) != 0 {
+		t.Fatal(val)
+	}
+	if len(val.Extensions) != 1 || val.Extensions[0].ExtensionType != 0x002a {
+		t.Fatal(val)
+	}
+
+	if v := buf.Bytes(); bytes.Compare(v, []byte{0x40}) != 0 {
+		t.Fatal(v)
+	}
+}
+
+func TestNewSessionTicketError_NoExtensions(t *testing.T) {
+	data := []byte{
+		// ticket lifetime
+		0x00, 0x01, 0x02, 0x03,
+		// ticket age add
+		0x10, 0x11, 0x12, 0x13,
+		// ticket nonce length
+		0x00,
+		// ticket length
+		0x00, 0x01,
+		// ticket
+		0x30,
+	}
+
+	var val recordfmt.NewSessionTicket
+	err := recordfmt.Unmarshal(data, &val, nil)
+	if errors.Cause(err) != recordfmt.ErrMalformed {
+		t.Fatal(err)
+	}
+	if v, ok := err.(*recordfmt.DecodeError); !ok || v.Type != "HelloExtensions" || v.Offset != 12 {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyUpdateUnmarshal(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// request update
+		0x01,
+
+		// debris
+		0x10,
+	})
+
+	var val recordfmt.KeyUpdate
+	if err := val.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if val.RequestUpdate != recordfmt.UpdateRequested {
+		t.Fatal(val)
+	}
+
+	if v := buf.Bytes(); bytes.Compare(v, []byte{0x10}) != 0 {
+		t.Fatal(v)
+	}
+}
+
+func TestCompressedCertificateUnmarshal(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// algorithm
+		0x00, 0x01,
+		// uncompressed length
+		0x00, 0x01, 0x00,
+		// compressed certificate message length
+		0x00, 0x00, 0x03,
+		// compressed certificate message
+		0x10, 0x11, 0x12,
+
+		// debris
+		0x20,
+	})
+
+	var val recordfmt.CompressedCertificate
+	if err := val.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if val.Algorithm != recordfmt.CompressionZlib || val.UncompressedLength != 0x100 {
+		t.Fatal(val)
+	}
+	if bytes.Compare(val.CompressedCertificateMessage, []byte{0x10, 0x11, 0x12}) != 0 {
+		t.Fatal(val)
+	}
+
+	if v := buf.Bytes(); bytes.Compare(v, []byte{0x20}) != 0 {
+		t.Fatal(v)
+	}
+}
+
+func TestCertificate13Unmarshal(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// certificate request context length
+		0x01,
+		// certificate request context
+		0x10,
+		// certificate list length
+		0x00, 0x00, 0x11,
+		// certificate list[0] cert data length
+		0x00, 0x00, 0x02,
+		// certificate list[0] cert data
+		0x20, 0x21,
+		// certificate list[0] extensions length
+		0x00, 0x00,
+		// certificate list[1] cert data length
+		0x00, 0x00, 0x01,
+		// certificate list[1] cert data
+		0x30,
+		// certificate list[1] extensions length
+		0x00, 0x04,
+		// certificate list[1] extension[0] type
+		0x00, 0x05,
+		// certificate list[1] extension[0] length
+		0x00, 0x00,
+
+		// debris
+		0x40,
+	})
+
+	var val recordfmt.Certificate13
+	if err := val.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Compare(val.CertificateRequestContext, []byte{0x10}) != 0 {
+		t.Fatal(val)
+	}
+	if len(val.CertificateList) != 2 {
+		t.Fatal(val)
+	}
+	if bytes.Compare(val.CertificateList[0].CertData, []byte{0x20, 0x21}) != 0 || len(val.CertificateList[0].Extensions) != 0 {
+		t.Fatal(val)
+	}
+	if bytes.Compare(val.CertificateList[1].CertData, []byte{0x30}) != 0 || len(val.CertificateList[1].Extensions) != 1 {
+		t.Fatal(val)
+	}
+	if val.CertificateList[1].Extensions[0].ExtensionType != 0x0005 {
+		t.Fatal(val)
+	}
+
+	if v := buf.Bytes(); bytes.Compare(v, []byte{0x40}) != 0 {
+		t.Fatal(v)
+	}
+}
+
+func TestCertificate13Error_NoExtensions(t *testing.T) {
+	data := []byte{
+		// certificate request context length
+		0x00,
+		// certificate list length
+		0x00, 0x00, 0x04,
+		// certificate list[0] cert data length
+		0x00, 0x00, 0x01,
+		// certificate list[0] cert data
+		0x20,
+	}
+
+	var val recordfmt.Certificate13
+	err := recordfmt.Unmarshal(data, &val, nil)
+	if errors.Cause(err) != recordfmt.ErrMalformed {
+		t.Fatal(err)
+	}
+	if v, ok := err.(*recordfmt.DecodeError); !ok || v.Type != "HelloExtensions" || v.Offset != 8 {
+		t.Fatal(err)
+	}
+}
+
+func TestCertificateVerifyUnmarshal(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// algorithm
+		0x08, 0x04,
+		// signature length
+		0x00, 0x03,
+		// signature
+		0x10, 0x11, 0x12,
+
+		// debris
+		0x20,
+	})
+
+	var val recordfmt.CertificateVerify
+	if err := val.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if val.Algorithm != 0x0804 {
+		t.Fatal(val)
+	}
+	if bytes.Compare(val.Signature, []byte{0x10, 0x11, 0x12}) != 0 {
+		t.Fatal(val)
+	}
+
+	if v := buf.Bytes(); bytes.Compare(v, []byte{0x20}) != 0 {
+		t.Fatal(v)
+	}
+}
+
+func TestMessageHashUnmarshal(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// hash
+		0x10, 0x11, 0x12, 0x13,
+	})
+
+	var val recordfmt.MessageHash
+	if err := val.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Compare(val, []byte{0x10, 0x11, 0x12, 0x13}) != 0 {
+		t.Fatal(val)
+	}
+}
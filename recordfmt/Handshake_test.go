@@ -179,6 +179,94 @@ func TestClientHelloUnmarshal_TLS12(t *testing.T) {
 	}
 }
 
+func TestClientHelloView(t *testing.T) {
+	data := []byte{
+		// client version
+		0x03, 0x03,
+		// client random
+		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+		0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+		// session id length
+		0x00,
+		// cipher suites length
+		0x00, 0x04,
+		// cipher suites
+		0x30, 0x31, 0x32, 0x33,
+		// compression methods length
+		0x01,
+		// compression methods[0]
+		0x00,
+
+		// extensions length
+		0x00, 0x0a,
+		// extension[0] type
+		0x50, 0x51,
+		// extension[0] length
+		0x00, 0x02,
+		// extension[0] data
+		0x60, 0x61,
+		// extension[1] type
+		0x52, 0x53,
+		// extension[1] length
+		0x00, 0x00,
+	}
+
+	var val recordfmt.ClientHelloView
+	if err := recordfmt.Unmarshal(data, &val, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if val.CipherSuites.Len() != 2 || val.CipherSuites.At(0) != 0x3031 || val.CipherSuites.At(1) != 0x3233 {
+		t.Fatal(val)
+	}
+	if val.CompressionMethods.Len() != 1 || val.CompressionMethods.At(0) != 0 {
+		t.Fatal(val)
+	}
+
+	var types []recordfmt.ExtensionType
+	for v, rest, ok := val.Extensions.Next(); ok; v, rest, ok = rest.Next() {
+		types = append(types, v.ExtensionType)
+	}
+	if len(types) != 2 || types[0] != 0x5051 || types[1] != 0x5253 {
+		t.Fatal(types)
+	}
+	if v, ok := val.Extensions.Find(0x5051); !ok || !bytes.Equal(v, []byte{0x60, 0x61}) {
+		t.Fatal(v, ok)
+	}
+
+	// the views share data, materializing copies nothing either.
+	hello := val.ClientHello()
+	data[49] = 0x70
+	if v, _ := val.Extensions.Find(0x5051); v[0] != 0x70 || hello.Extensions[0].ExtensionData[0] != 0x70 {
+		t.Fatal(v, hello)
+	}
+	if len(hello.CipherSuites) != 2 || hello.CipherSuites[1] != 0x3233 {
+		t.Fatal(hello)
+	}
+}
+
+func TestHelloExtensionsViewError_Truncated(t *testing.T) {
+	data := []byte{
+		// extensions length
+		0x00, 0x07,
+		// extension[0] type
+		0x50, 0x51,
+		// extension[0] length
+		0x00, 0x05,
+		// extension[0] data, overruns the vector
+		0x60, 0x61, 0x62,
+
+		// next vector
+		0x70, 0x71,
+	}
+
+	var val recordfmt.HelloExtensionsView
+	_, err := recordfmt.Parse(data, &val, nil)
+	if v, ok := err.(*recordfmt.DecodeError); !ok || v.Type != "ExtensionData" || v.Offset != 6 {
+		t.Fatal(err)
+	}
+}
+
 func TestServerHelloUnmarshal_TLS12(t *testing.T) {
 	buf := bytes.NewBuffer([]byte{
 		// server version
@@ -245,3 +333,78 @@ func TestServerHelloUnmarshal_TLS12(t *testing.T) {
 		t.Fatal(v)
 	}
 }
+
+func TestClientHelloUnmarshal_TLS10(t *testing.T) {
+	body := []byte{
+		// client version
+		0x03, 0x01,
+		// client random
+		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+		0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+		// session id length
+		0x00,
+		// cipher suites length
+		0x00, 0x02,
+		// cipher suites[0]
+		0x00, 0x2f,
+		// compression methods length
+		0x01,
+		// compression methods[0]
+		0x00,
+	}
+	ext := []byte{
+		// extensions length
+		0x00, 0x05,
+		// extension[0] type
+		0x00, 0x0b,
+		// extension[0] length
+		0x00, 0x01,
+		// extension[0] data
+		0x00,
+	}
+
+	// extensions are not a TLS 1.2 feature, TLS 1.0 clients send them too.
+	var val recordfmt.ClientHello
+	if err := recordfmt.Unmarshal(append(append([]byte{}, body...), ext...), &val, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(val.Extensions) != 1 || val.Extensions[0].ExtensionType != recordfmt.ExtensionECPointFormats {
+		t.Fatal(val)
+	}
+
+	// and they may be omitted altogether.
+	if err := val.Decode(bytes.NewReader(body)); err != nil || len(val.Extensions) != 0 {
+		t.Fatal(val, err)
+	}
+}
+
+func TestServerHelloUnmarshal_TLS11(t *testing.T) {
+	buf := []byte{
+		// server version
+		0x03, 0x02,
+		// server random
+		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+		0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+		// session id length
+		0x00,
+		// cipher suite
+		0x00, 0x2f,
+		// compression method
+		0x00,
+
+		// extensions length
+		0x00, 0x04,
+		// extension[0] type
+		0xff, 0x01,
+		// extension[0] length
+		0x00, 0x00,
+	}
+
+	var val recordfmt.ServerHello
+	if err := recordfmt.Unmarshal(buf, &val, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(val.Extensions) != 1 || val.Extensions[0].ExtensionType != recordfmt.ExtensionRenegotiationInfo {
+		t.Fatal(val)
+	}
+}
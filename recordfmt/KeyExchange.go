@@ -0,0 +1,284 @@
+package recordfmt
+
+import (
+	"crypto/tls"
+	"io"
+)
+
+// KeyExchangeAlgorithm -
+type KeyExchangeAlgorithm int
+
+// -
+const (
+	KeyExchangeUnknown = KeyExchangeAlgorithm(iota)
+	KeyExchangeRSA
+	KeyExchangeDHE
+	KeyExchangeECDHE
+)
+
+var (
+	// from the IANA registry, crypto/tls only knows the suites it implements.
+	suite2keyExchange = map[CipherSuite]KeyExchangeAlgorithm{
+		0x0001: KeyExchangeRSA,   //RSA_WITH_NULL_MD5
+		0x0002: KeyExchangeRSA,   //RSA_WITH_NULL_SHA
+		0x0003: KeyExchangeRSA,   //RSA_EXPORT_WITH_RC4_40_MD5
+		0x0004: KeyExchangeRSA,   //RSA_WITH_RC4_128_MD5
+		0x0005: KeyExchangeRSA,   //RSA_WITH_RC4_128_SHA
+		0x0006: KeyExchangeRSA,   //RSA_EXPORT_WITH_RC2_CBC_40_MD5
+		0x0007: KeyExchangeRSA,   //RSA_WITH_IDEA_CBC_SHA
+		0x0008: KeyExchangeRSA,   //RSA_EXPORT_WITH_DES40_CBC_SHA
+		0x0009: KeyExchangeRSA,   //RSA_WITH_DES_CBC_SHA
+		0x000A: KeyExchangeRSA,   //RSA_WITH_3DES_EDE_CBC_SHA
+		0x0011: KeyExchangeDHE,   //DHE_DSS_EXPORT_WITH_DES40_CBC_SHA
+		0x0012: KeyExchangeDHE,   //DHE_DSS_WITH_DES_CBC_SHA
+		0x0013: KeyExchangeDHE,   //DHE_DSS_WITH_3DES_EDE_CBC_SHA
+		0x0014: KeyExchangeDHE,   //DHE_RSA_EXPORT_WITH_DES40_CBC_SHA
+		0x0015: KeyExchangeDHE,   //DHE_RSA_WITH_DES_CBC_SHA
+		0x0016: KeyExchangeDHE,   //DHE_RSA_WITH_3DES_EDE_CBC_SHA
+		0x002F: KeyExchangeRSA,   //RSA_WITH_AES_128_CBC_SHA
+		0x0032: KeyExchangeDHE,   //DHE_DSS_WITH_AES_128_CBC_SHA
+		0x0033: KeyExchangeDHE,   //DHE_RSA_WITH_AES_128_CBC_SHA
+		0x0035: KeyExchangeRSA,   //RSA_WITH_AES_256_CBC_SHA
+		0x0038: KeyExchangeDHE,   //DHE_DSS_WITH_AES_256_CBC_SHA
+		0x0039: KeyExchangeDHE,   //DHE_RSA_WITH_AES_256_CBC_SHA
+		0x003B: KeyExchangeRSA,   //RSA_WITH_NULL_SHA256
+		0x003C: KeyExchangeRSA,   //RSA_WITH_AES_128_CBC_SHA256
+		0x003D: KeyExchangeRSA,   //RSA_WITH_AES_256_CBC_SHA256
+		0x0040: KeyExchangeDHE,   //DHE_DSS_WITH_AES_128_CBC_SHA256
+		0x0041: KeyExchangeRSA,   //RSA_WITH_CAMELLIA_128_CBC_SHA
+		0x0044: KeyExchangeDHE,   //DHE_DSS_WITH_CAMELLIA_128_CBC_SHA
+		0x0045: KeyExchangeDHE,   //DHE_RSA_WITH_CAMELLIA_128_CBC_SHA
+		0x0067: KeyExchangeDHE,   //DHE_RSA_WITH_AES_128_CBC_SHA256
+		0x006A: KeyExchangeDHE,   //DHE_DSS_WITH_AES_256_CBC_SHA256
+		0x006B: KeyExchangeDHE,   //DHE_RSA_WITH_AES_256_CBC_SHA256
+		0x0084: KeyExchangeRSA,   //RSA_WITH_CAMELLIA_256_CBC_SHA
+		0x0087: KeyExchangeDHE,   //DHE_DSS_WITH_CAMELLIA_256_CBC_SHA
+		0x0088: KeyExchangeDHE,   //DHE_RSA_WITH_CAMELLIA_256_CBC_SHA
+		0x0096: KeyExchangeRSA,   //RSA_WITH_SEED_CBC_SHA
+		0x0099: KeyExchangeDHE,   //DHE_DSS_WITH_SEED_CBC_SHA
+		0x009A: KeyExchangeDHE,   //DHE_RSA_WITH_SEED_CBC_SHA
+		0x009C: KeyExchangeRSA,   //RSA_WITH_AES_128_GCM_SHA256
+		0x009D: KeyExchangeRSA,   //RSA_WITH_AES_256_GCM_SHA384
+		0x009E: KeyExchangeDHE,   //DHE_RSA_WITH_AES_128_GCM_SHA256
+		0x009F: KeyExchangeDHE,   //DHE_RSA_WITH_AES_256_GCM_SHA384
+		0x00A2: KeyExchangeDHE,   //DHE_DSS_WITH_AES_128_GCM_SHA256
+		0x00A3: KeyExchangeDHE,   //DHE_DSS_WITH_AES_256_GCM_SHA384
+		0x00BA: KeyExchangeRSA,   //RSA_WITH_CAMELLIA_128_CBC_SHA256
+		0x00BD: KeyExchangeDHE,   //DHE_DSS_WITH_CAMELLIA_128_CBC_SHA256
+		0x00BE: KeyExchangeDHE,   //DHE_RSA_WITH_CAMELLIA_128_CBC_SHA256
+		0x00C0: KeyExchangeRSA,   //RSA_WITH_CAMELLIA_256_CBC_SHA256
+		0x00C3: KeyExchangeDHE,   //DHE_DSS_WITH_CAMELLIA_256_CBC_SHA256
+		0x00C4: KeyExchangeDHE,   //DHE_RSA_WITH_CAMELLIA_256_CBC_SHA256
+		0xC006: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_NULL_SHA
+		0xC007: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_RC4_128_SHA
+		0xC008: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_3DES_EDE_CBC_SHA
+		0xC009: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_AES_128_CBC_SHA
+		0xC00A: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_AES_256_CBC_SHA
+		0xC010: KeyExchangeECDHE, //ECDHE_RSA_WITH_NULL_SHA
+		0xC011: KeyExchangeECDHE, //ECDHE_RSA_WITH_RC4_128_SHA
+		0xC012: KeyExchangeECDHE, //ECDHE_RSA_WITH_3DES_EDE_CBC_SHA
+		0xC013: KeyExchangeECDHE, //ECDHE_RSA_WITH_AES_128_CBC_SHA
+		0xC014: KeyExchangeECDHE, //ECDHE_RSA_WITH_AES_256_CBC_SHA
+		0xC023: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_AES_128_CBC_SHA256
+		0xC024: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_AES_256_CBC_SHA384
+		0xC027: KeyExchangeECDHE, //ECDHE_RSA_WITH_AES_128_CBC_SHA256
+		0xC028: KeyExchangeECDHE, //ECDHE_RSA_WITH_AES_256_CBC_SHA384
+		0xC02B: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_AES_128_GCM_SHA256
+		0xC02C: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_AES_256_GCM_SHA384
+		0xC02F: KeyExchangeECDHE, //ECDHE_RSA_WITH_AES_128_GCM_SHA256
+		0xC030: KeyExchangeECDHE, //ECDHE_RSA_WITH_AES_256_GCM_SHA384
+		0xC03C: KeyExchangeRSA,   //RSA_WITH_ARIA_128_CBC_SHA256
+		0xC03D: KeyExchangeRSA,   //RSA_WITH_ARIA_256_CBC_SHA384
+		0xC042: KeyExchangeDHE,   //DHE_DSS_WITH_ARIA_128_CBC_SHA256
+		0xC043: KeyExchangeDHE,   //DHE_DSS_WITH_ARIA_256_CBC_SHA384
+		0xC044: KeyExchangeDHE,   //DHE_RSA_WITH_ARIA_128_CBC_SHA256
+		0xC045: KeyExchangeDHE,   //DHE_RSA_WITH_ARIA_256_CBC_SHA384
+		0xC048: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_ARIA_128_CBC_SHA256
+		0xC049: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_ARIA_256_CBC_SHA384
+		0xC04C: KeyExchangeECDHE, //ECDHE_RSA_WITH_ARIA_128_CBC_SHA256
+		0xC04D: KeyExchangeECDHE, //ECDHE_RSA_WITH_ARIA_256_CBC_SHA384
+		0xC050: KeyExchangeRSA,   //RSA_WITH_ARIA_128_GCM_SHA256
+		0xC051: KeyExchangeRSA,   //RSA_WITH_ARIA_256_GCM_SHA384
+		0xC052: KeyExchangeDHE,   //DHE_RSA_WITH_ARIA_128_GCM_SHA256
+		0xC053: KeyExchangeDHE,   //DHE_RSA_WITH_ARIA_256_GCM_SHA384
+		0xC056: KeyExchangeDHE,   //DHE_DSS_WITH_ARIA_128_GCM_SHA256
+		0xC057: KeyExchangeDHE,   //DHE_DSS_WITH_ARIA_256_GCM_SHA384
+		0xC05C: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_ARIA_128_GCM_SHA256
+		0xC05D: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_ARIA_256_GCM_SHA384
+		0xC060: KeyExchangeECDHE, //ECDHE_RSA_WITH_ARIA_128_GCM_SHA256
+		0xC061: KeyExchangeECDHE, //ECDHE_RSA_WITH_ARIA_256_GCM_SHA384
+		0xC072: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_CAMELLIA_128_CBC_SHA256
+		0xC073: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_CAMELLIA_256_CBC_SHA384
+		0xC076: KeyExchangeECDHE, //ECDHE_RSA_WITH_CAMELLIA_128_CBC_SHA256
+		0xC077: KeyExchangeECDHE, //ECDHE_RSA_WITH_CAMELLIA_256_CBC_SHA384
+		0xC07A: KeyExchangeRSA,   //RSA_WITH_CAMELLIA_128_GCM_SHA256
+		0xC07B: KeyExchangeRSA,   //RSA_WITH_CAMELLIA_256_GCM_SHA384
+		0xC07C: KeyExchangeDHE,   //DHE_RSA_WITH_CAMELLIA_128_GCM_SHA256
+		0xC07D: KeyExchangeDHE,   //DHE_RSA_WITH_CAMELLIA_256_GCM_SHA384
+		0xC080: KeyExchangeDHE,   //DHE_DSS_WITH_CAMELLIA_128_GCM_SHA256
+		0xC081: KeyExchangeDHE,   //DHE_DSS_WITH_CAMELLIA_256_GCM_SHA384
+		0xC086: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_CAMELLIA_128_GCM_SHA256
+		0xC087: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_CAMELLIA_256_GCM_SHA384
+		0xC08A: KeyExchangeECDHE, //ECDHE_RSA_WITH_CAMELLIA_128_GCM_SHA256
+		0xC08B: KeyExchangeECDHE, //ECDHE_RSA_WITH_CAMELLIA_256_GCM_SHA384
+		0xC09C: KeyExchangeRSA,   //RSA_WITH_AES_128_CCM
+		0xC09D: KeyExchangeRSA,   //RSA_WITH_AES_256_CCM
+		0xC09E: KeyExchangeDHE,   //DHE_RSA_WITH_AES_128_CCM
+		0xC09F: KeyExchangeDHE,   //DHE_RSA_WITH_AES_256_CCM
+		0xC0A0: KeyExchangeRSA,   //RSA_WITH_AES_128_CCM_8
+		0xC0A1: KeyExchangeRSA,   //RSA_WITH_AES_256_CCM_8
+		0xC0A2: KeyExchangeDHE,   //DHE_RSA_WITH_AES_128_CCM_8
+		0xC0A3: KeyExchangeDHE,   //DHE_RSA_WITH_AES_256_CCM_8
+		0xC0AC: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_AES_128_CCM
+		0xC0AD: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_AES_256_CCM
+		0xC0AE: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_AES_128_CCM_8
+		0xC0AF: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_AES_256_CCM_8
+		0xCCA8: KeyExchangeECDHE, //ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256
+		0xCCA9: KeyExchangeECDHE, //ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256
+		0xCCAA: KeyExchangeDHE,   //DHE_RSA_WITH_CHACHA20_POLY1305_SHA256
+	}
+)
+
+// KeyExchange -
+func (s CipherSuite) KeyExchange() KeyExchangeAlgorithm {
+	return suite2keyExchange[s]
+}
+
+// ServerECDHParams -
+type ServerECDHParams struct {
+	CurveType  uint8
+	NamedCurve NamedGroup
+	Public     []byte
+}
+
+// Decode -
+func (s *ServerECDHParams) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *ServerECDHParams) parse(d *Reader) (err error) {
+	var v ServerECDHParams
+
+	if v.CurveType, err = d.readUint8("CurveType"); err == nil {
+		if err = v.NamedCurve.parse(d); err == nil {
+			v.Public, err = d.readOpaque8("Public")
+		}
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
+
+// ServerDHParams -
+type ServerDHParams struct {
+	P  []byte
+	G  []byte
+	Ys []byte
+}
+
+// Decode -
+func (s *ServerDHParams) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *ServerDHParams) parse(d *Reader) (err error) {
+	var v ServerDHParams
+
+	if v.P, err = d.readOpaque16("P"); err == nil {
+		if v.G, err = d.readOpaque16("G"); err == nil {
+			v.Ys, err = d.readOpaque16("Ys")
+		}
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
+
+// ServerKeyExchange -
+type ServerKeyExchange struct {
+	Params    []byte
+	Algorithm SignatureScheme
+	Signature Signature
+}
+
+func (s *ServerKeyExchange) parse(d *Reader, version ProtocolVersion, kx KeyExchangeAlgorithm) (err error) {
+	var v ServerKeyExchange
+
+	// signature covers the raw params, keep them as they were on the wire.
+	m := d.mark()
+	if kx == KeyExchangeECDHE {
+		var params ServerECDHParams
+		err = params.parse(d)
+	} else {
+		var params ServerDHParams
+		err = params.parse(d)
+	}
+	v.Params = d.since(m)
+
+	if err == nil && version >= tls.VersionTLS12 {
+		err = v.Algorithm.parse(d)
+	}
+	if err == nil {
+		err = v.Signature.parse(d)
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
+
+// DecodeECDHE -
+func (s *ServerKeyExchange) DecodeECDHE(r io.Reader, version ProtocolVersion) error {
+	return s.parse(newReader(r), version, KeyExchangeECDHE)
+}
+
+// DecodeDHE -
+func (s *ServerKeyExchange) DecodeDHE(r io.Reader, version ProtocolVersion) error {
+	return s.parse(newReader(r), version, KeyExchangeDHE)
+}
+
+// ECDHParams -
+func (s *ServerKeyExchange) ECDHParams() (r *ServerECDHParams, err error) {
+	var v ServerECDHParams
+	if err = Unmarshal(s.Params, &v, nil); err == nil {
+		r = &v
+	}
+	return
+}
+
+// DHParams -
+func (s *ServerKeyExchange) DHParams() (r *ServerDHParams, err error) {
+	var v ServerDHParams
+	if err = Unmarshal(s.Params, &v, nil); err == nil {
+		r = &v
+	}
+	return
+}
+
+// ClientKeyExchange -
+type ClientKeyExchange struct {
+	EncryptedPreMasterSecret []byte
+}
+
+// DecodeRSA -
+func (s *ClientKeyExchange) DecodeRSA(r io.Reader, version ProtocolVersion) (err error) {
+	var v ClientKeyExchange
+
+	d := newReader(r)
+	if version > 0x0300 {
+		v.EncryptedPreMasterSecret, err = d.readOpaque16("EncryptedPreMasterSecret")
+	} else {
+		// SSL 3.0 sends the ciphertext without a length.
+		v.EncryptedPreMasterSecret, err = d.readRest("EncryptedPreMasterSecret")
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
@@ -0,0 +1,170 @@
+package recordfmt_test
+
+import (
+	"bytes"
+	"crypto/tls"
+	"strings"
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+)
+
+func TestServerKeyExchangeUnmarshal_ECDHE(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// curve type
+		0x03,
+		// named curve
+		0x00, 0x1d,
+		// public length
+		0x02,
+		// public
+		0x10, 0x11,
+		// signature algorithm
+		0x08, 0x04,
+		// signature length
+		0x00, 0x02,
+		// signature
+		0x20, 0x21,
+
+		// debris
+		0x30,
+	})
+
+	var val recordfmt.ServerKeyExchange
+	if err := val.DecodeECDHE(buf, tls.VersionTLS12); err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Compare(val.Params, []byte{0x03, 0x00, 0x1d, 0x02, 0x10, 0x11}) != 0 {
+		t.Fatal(val)
+	}
+	if val.Algorithm != 0x0804 || bytes.Compare(val.Signature, []byte{0x20, 0x21}) != 0 {
+		t.Fatal(val)
+	}
+
+	params, err := val.ECDHParams()
+	if err != nil || params.CurveType != 3 || params.NamedCurve != 0x001d || bytes.Compare(params.Public, []byte{0x10, 0x11}) != 0 {
+		t.Fatal(params, err)
+	}
+
+	if v := buf.Bytes(); bytes.Compare(v, []byte{0x30}) != 0 {
+		t.Fatal(v)
+	}
+}
+
+func TestServerKeyExchangeUnmarshal_DHE_TLS10(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// p length
+		0x00, 0x01,
+		// p
+		0x10,
+		// g length
+		0x00, 0x01,
+		// g
+		0x02,
+		// ys length
+		0x00, 0x02,
+		// ys
+		0x20, 0x21,
+		// signature length
+		0x00, 0x01,
+		// signature
+		0x30,
+
+		// debris
+		0x40,
+	})
+
+	var val recordfmt.ServerKeyExchange
+	if err := val.DecodeDHE(buf, tls.VersionTLS10); err != nil {
+		t.Fatal(err)
+	}
+
+	if val.Algorithm != 0 || bytes.Compare(val.Signature, []byte{0x30}) != 0 {
+		t.Fatal(val)
+	}
+
+	params, err := val.DHParams()
+	if err != nil || bytes.Compare(params.P, []byte{0x10}) != 0 || bytes.Compare(params.Ys, []byte{0x20, 0x21}) != 0 {
+		t.Fatal(params, err)
+	}
+
+	if v := buf.Bytes(); bytes.Compare(v, []byte{0x40}) != 0 {
+		t.Fatal(v)
+	}
+}
+
+func TestCipherSuite_KeyExchange(t *testing.T) {
+	for suite, kx := range map[uint16]recordfmt.KeyExchangeAlgorithm{
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256: recordfmt.KeyExchangeECDHE,
+		tls.TLS_RSA_WITH_AES_128_GCM_SHA256:       recordfmt.KeyExchangeRSA,
+		tls.TLS_AES_128_GCM_SHA256:                recordfmt.KeyExchangeUnknown,
+
+		// suites crypto/tls does not implement.
+		0x003d: recordfmt.KeyExchangeRSA,     // TLS_RSA_WITH_AES_256_CBC_SHA256
+		0x0004: recordfmt.KeyExchangeRSA,     // TLS_RSA_WITH_RC4_128_MD5
+		0x0084: recordfmt.KeyExchangeRSA,     // TLS_RSA_WITH_CAMELLIA_256_CBC_SHA
+		0x009e: recordfmt.KeyExchangeDHE,     // TLS_DHE_RSA_WITH_AES_128_GCM_SHA256
+		0x0033: recordfmt.KeyExchangeDHE,     // TLS_DHE_RSA_WITH_AES_128_CBC_SHA
+		0x0039: recordfmt.KeyExchangeDHE,     // TLS_DHE_RSA_WITH_AES_256_CBC_SHA
+		0x0016: recordfmt.KeyExchangeDHE,     // TLS_DHE_RSA_WITH_3DES_EDE_CBC_SHA
+		0xc0ad: recordfmt.KeyExchangeECDHE,   // TLS_ECDHE_ECDSA_WITH_AES_256_CCM
+		0x0018: recordfmt.KeyExchangeUnknown, // TLS_DH_anon_WITH_RC4_128_MD5
+	} {
+		if v := recordfmt.CipherSuite(suite).KeyExchange(); v != kx {
+			t.Fatal(suite, v)
+		}
+	}
+
+	// the table agrees with crypto/tls wherever both know a suite.
+	prefixes := map[string]recordfmt.KeyExchangeAlgorithm{
+		"TLS_RSA_":   recordfmt.KeyExchangeRSA,
+		"TLS_DHE_":   recordfmt.KeyExchangeDHE,
+		"TLS_ECDHE_": recordfmt.KeyExchangeECDHE,
+	}
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		kx := recordfmt.KeyExchangeUnknown
+		for prefix, w := range prefixes {
+			if strings.HasPrefix(suite.Name, prefix) {
+				kx = w
+			}
+		}
+		if v := recordfmt.CipherSuite(suite.ID).KeyExchange(); v != kx {
+			t.Fatal(suite.Name, v)
+		}
+	}
+}
+
+func TestClientKeyExchangeUnmarshal_RSA(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// encrypted pre-master secret length
+		0x00, 0x02,
+		// encrypted pre-master secret
+		0x10, 0x11,
+
+		// debris
+		0x30,
+	})
+
+	var val recordfmt.ClientKeyExchange
+	if err := val.DecodeRSA(buf, tls.VersionTLS12); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(val.EncryptedPreMasterSecret, []byte{0x10, 0x11}) != 0 {
+		t.Fatal(val)
+	}
+	if v := buf.Bytes(); bytes.Compare(v, []byte{0x30}) != 0 {
+		t.Fatal(v)
+	}
+
+	if err := val.DecodeRSA(bytes.NewReader([]byte{0x10, 0x11, 0x12}), 0x0300); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Compare(val.EncryptedPreMasterSecret, []byte{0x10, 0x11, 0x12}) != 0 {
+		t.Fatal(val)
+	}
+
+	if err := val.DecodeRSA(bytes.NewReader([]byte{0x00, 0x04, 0x10}), tls.VersionTLS12); err == nil {
+		t.Fatal(val)
+	}
+}
@@ -0,0 +1,444 @@
+package recordfmt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DecodeOptions -
+type DecodeOptions struct {
+	MaxFragmentLength  int
+	MaxHandshakeLength int
+	MaxVectorLength    int
+	Strict             bool
+}
+
+// -
+var (
+	ErrTooLarge     = fmt.Errorf("Too Large")
+	ErrTrailingData = fmt.Errorf("Trailing Data")
+	ErrMalformed    = fmt.Errorf("Malformed")
+
+	DefaultDecodeOptions = DecodeOptions{
+		MaxFragmentLength:  16384 + 2048,
+		MaxHandshakeLength: 262144,
+		MaxVectorLength:    65535,
+	}
+)
+
+// DecodeError -
+type DecodeError struct {
+	Type   string
+	Offset int64
+	Reason string
+	Err    error
+}
+
+func (s *DecodeError) Error() string {
+	return fmt.Sprintf("%s at offset %d: %s", s.Type, s.Offset, s.Reason)
+}
+
+// Cause -
+func (s *DecodeError) Cause() error {
+	return s.Err
+}
+
+// Unwrap -
+func (s *DecodeError) Unwrap() error {
+	return s.Err
+}
+
+// Decoder -
+type Decoder interface {
+	Decode(io.Reader) error
+}
+
+// every type here decodes from a Reader directly, Decode only wraps that.
+type parser interface {
+	parse(*Reader) error
+}
+
+// Reader -
+type Reader struct {
+	r       io.Reader
+	data    []byte
+	base    int64
+	offset  int64
+	length  int64
+	options DecodeOptions
+	tee     *bytes.Buffer
+	scratch [4]byte
+}
+
+func normalizeOptions(options *DecodeOptions) DecodeOptions {
+	v := DefaultDecodeOptions
+	if options != nil {
+		v.Strict = options.Strict
+		if options.MaxFragmentLength > 0 {
+			v.MaxFragmentLength = options.MaxFragmentLength
+		}
+		if options.MaxHandshakeLength > 0 {
+			v.MaxHandshakeLength = options.MaxHandshakeLength
+		}
+		if options.MaxVectorLength > 0 {
+			v.MaxVectorLength = options.MaxVectorLength
+		}
+	}
+	return v
+}
+
+// NewReader -
+func NewReader(r io.Reader, options *DecodeOptions) *Reader {
+	return &Reader{r: r, length: -1, options: normalizeOptions(options)}
+}
+
+// NewBytesReader -
+func NewBytesReader(data []byte, options *DecodeOptions) *Reader {
+	return &Reader{data: data, length: int64(len(data)), options: normalizeOptions(options)}
+}
+
+func newReader(r io.Reader) *Reader {
+	if v, ok := r.(*Reader); ok {
+		return v
+	}
+	return NewReader(r, nil)
+}
+
+// Offset -
+func (s *Reader) Offset() int64 {
+	return s.offset
+}
+
+// Options -
+func (s *Reader) Options() DecodeOptions {
+	return s.options
+}
+
+// Bytes -
+func (s *Reader) Bytes() []byte {
+	if s.r == nil {
+		return s.data[s.offset-s.base : s.length-s.base]
+	}
+	return nil
+}
+
+// Read -
+func (s *Reader) Read(p []byte) (n int, err error) {
+	if s.r == nil {
+		if n = copy(p, s.Bytes()); n == 0 && len(p) > 0 {
+			err = io.EOF
+		}
+	} else {
+		n, err = s.r.Read(p)
+	}
+
+	s.offset += int64(n)
+	if s.tee != nil {
+		s.tee.Write(p[:n]) // always success
+	}
+	return
+}
+
+func (s *Reader) remaining() int64 {
+	if s.length < 0 {
+		return -1
+	}
+	return s.length - s.offset
+}
+
+func (s *Reader) fail(typ string, offset int64, err error, reason string) error {
+	return &DecodeError{Type: typ, Offset: offset, Reason: reason, Err: err}
+}
+
+func (s *Reader) view(typ string, n int) (v []byte, err error) {
+	offset := s.offset
+	if r := s.remaining(); r == 0 && n > 0 {
+		err = s.fail(typ, offset, io.EOF, "truncated")
+	} else if int64(n) > r {
+		err = s.fail(typ, offset, io.ErrUnexpectedEOF, "truncated")
+	} else {
+		pos := int(s.offset - s.base)
+		v = s.data[pos : pos+n : pos+n]
+		s.offset += int64(n)
+		if s.tee != nil {
+			s.tee.Write(v) // always success
+		}
+	}
+	return
+}
+
+func (s *Reader) fixed(typ string, n int) (v []byte, err error) {
+	if s.r == nil {
+		return s.view(typ, n)
+	}
+
+	offset := s.offset
+	v = s.scratch[:n]
+	if _, err = io.ReadFull(s, v); err != nil {
+		err = s.fail(typ, offset, err, "truncated")
+	}
+	return
+}
+
+func (s *Reader) readUint8(typ string) (v uint8, err error) {
+	var raw []byte
+	if raw, err = s.fixed(typ, 1); err == nil {
+		v = raw[0]
+	}
+	return
+}
+
+func (s *Reader) readUint16(typ string) (v uint16, err error) {
+	var raw []byte
+	if raw, err = s.fixed(typ, 2); err == nil {
+		v = binary.BigEndian.Uint16(raw)
+	}
+	return
+}
+
+func (s *Reader) readUint24(typ string) (v int, err error) {
+	var raw []byte
+	if raw, err = s.fixed(typ, 3); err == nil {
+		v = (int(raw[0]) << 16) + (int(raw[1]) << 8) + int(raw[2])
+	}
+	return
+}
+
+func (s *Reader) readUint32(typ string) (v uint32, err error) {
+	var raw []byte
+	if raw, err = s.fixed(typ, 4); err == nil {
+		v = binary.BigEndian.Uint32(raw)
+	}
+	return
+}
+
+func (s *Reader) readOpaque(typ string, n int) (v []byte, err error) {
+	if s.r == nil {
+		return s.view(typ, n)
+	}
+
+	offset := s.offset
+
+	// never trust the peer's length, grow only with what actually arrives.
+	if r := s.remaining(); r >= 0 && int64(n) > r {
+		err = s.fail(typ, offset, io.ErrUnexpectedEOF, "truncated")
+	} else if n <= 4096 {
+		v = make([]byte, n)
+		if _, err = io.ReadFull(s, v); err != nil {
+			v, err = nil, s.fail(typ, offset, err, "truncated")
+		}
+	} else {
+		var buf bytes.Buffer
+		if _, err = io.CopyN(&buf, s, int64(n)); err == nil {
+			v = buf.Bytes()
+		} else {
+			err = s.fail(typ, offset, io.ErrUnexpectedEOF, "truncated")
+		}
+	}
+	return
+}
+
+func (s *Reader) readRest(typ string) (v []byte, err error) {
+	if s.r == nil {
+		return s.view(typ, int(s.remaining()))
+	}
+
+	offset := s.offset
+	if v, err = ioutil.ReadAll(s); err != nil {
+		err = s.fail(typ, offset, err, "truncated")
+	}
+	return
+}
+
+func (s *Reader) readVector(typ string, size int, limit int) (v []byte, err error) {
+	offset := s.offset
+
+	var n int
+	switch size {
+	case 1:
+		var aux uint8
+		aux, err = s.readUint8(typ)
+		n = int(aux)
+	case 2:
+		var aux uint16
+		aux, err = s.readUint16(typ)
+		n = int(aux)
+	case 3:
+		n, err = s.readUint24(typ)
+	}
+
+	if err == nil {
+		if limit > 0 && n > limit {
+			err = s.fail(typ, offset, ErrTooLarge, fmt.Sprintf("length %d exceeds limit %d", n, limit))
+		} else {
+			v, err = s.readOpaque(typ, n)
+		}
+	}
+	return
+}
+
+func (s *Reader) readOpaque8(typ string) ([]byte, error) {
+	return s.readVector(typ, 1, s.options.MaxVectorLength)
+}
+
+func (s *Reader) readOpaque16(typ string) ([]byte, error) {
+	return s.readVector(typ, 2, s.options.MaxVectorLength)
+}
+
+func (s *Reader) readOpaque24(typ string) ([]byte, error) {
+	return s.readVector(typ, 3, s.options.MaxHandshakeLength)
+}
+
+type mark struct {
+	offset int64
+	tee    *bytes.Buffer
+}
+
+// mark starts remembering what is read, for since to return as it was on the
+// wire.
+func (s *Reader) mark() (m mark) {
+	m.offset = s.offset
+	if s.r != nil {
+		m.tee, s.tee = s.tee, &bytes.Buffer{}
+	}
+	return
+}
+
+func (s *Reader) since(m mark) (v []byte) {
+	if s.r == nil {
+		return s.data[m.offset-s.base : s.offset-s.base : s.offset-s.base]
+	}
+
+	v = s.tee.Bytes()
+	if m.tee != nil {
+		m.tee.Write(v) // always success
+	}
+	s.tee = m.tee
+	return
+}
+
+func (s *Reader) sub(v []byte) *Reader {
+	// nested vectors keep reporting offsets relative to the outermost input.
+	offset := s.offset - int64(len(v))
+	return &Reader{
+		data:    v,
+		base:    offset,
+		offset:  offset,
+		length:  offset + int64(len(v)),
+		options: s.options,
+	}
+}
+
+// enter returns a reader over the vector v just read from s. In memory mode
+// that is s itself narrowed to v, so walking a vector allocates no reader;
+// the caller hands the result back to leave once done.
+func (s *Reader) enter(v []byte) (r *Reader, limit int64) {
+	if s.r != nil {
+		return s.sub(v), s.length
+	}
+	limit = s.length
+	s.offset, s.length = s.offset-int64(len(v)), s.offset
+	return s, limit
+}
+
+// leave widens s again after enter, positioned just past the vector.
+func (s *Reader) leave(r *Reader, limit int64) {
+	if r == s {
+		s.offset, s.length = s.length, limit
+	}
+}
+
+// elements checks that v, a vector just read, holds whole elements of n bytes.
+func (s *Reader) elements(typ string, v []byte, n int) (err error) {
+	if r := len(v) % n; r != 0 {
+		err = s.fail(typ, s.offset-int64(r), io.ErrUnexpectedEOF, "truncated")
+	}
+	return
+}
+
+// whole reports whether v splits into elements of skip fixed bytes and a
+// size-byte length within limit, so sound vectors skip the Reader walk.
+func whole(v []byte, skip, size, limit int) bool {
+	for len(v) > 0 {
+		if len(v) < skip+size {
+			return false
+		}
+		n := 0
+		for _, c := range v[skip : skip+size] {
+			n = n<<8 | int(c)
+		}
+		if (limit > 0 && n > limit) || n > len(v)-skip-size {
+			return false
+		}
+		v = v[skip+size+n:]
+	}
+	return true
+}
+
+func (s *Reader) more() bool {
+	return s.remaining() > 0
+}
+
+func (s *Reader) finish(typ string) (err error) {
+	if r := s.remaining(); s.options.Strict && r > 0 {
+		err = s.fail(typ, s.offset, ErrTrailingData, fmt.Sprintf("%d trailing bytes", r))
+	}
+	return
+}
+
+func typeName(v Decoder) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", v), "*recordfmt.")
+}
+
+func decode(r *Reader, v Decoder) error {
+	if p, ok := v.(parser); ok {
+		return p.parse(r)
+	}
+	return v.Decode(r)
+}
+
+// nothing keeps a Reader over bytes once parsed, so bulk parsing reuses them.
+var bytesReaders = sync.Pool{
+	New: func() interface{} {
+		return &Reader{}
+	},
+}
+
+func borrowBytesReader(data []byte, options *DecodeOptions) *Reader {
+	r := bytesReaders.Get().(*Reader)
+	*r = Reader{data: data, length: int64(len(data)), options: normalizeOptions(options)}
+	return r
+}
+
+// Parse -
+func Parse(data []byte, v Decoder, options *DecodeOptions) (rest []byte, err error) {
+	r := borrowBytesReader(data, options)
+
+	if err = decode(r, v); err == nil {
+		rest = r.Bytes()
+	}
+	bytesReaders.Put(r)
+	return
+}
+
+// Unmarshal -
+func Unmarshal(data []byte, v Decoder, options *DecodeOptions) (err error) {
+	r := borrowBytesReader(data, options)
+
+	if err = decode(r, v); err == nil && r.more() {
+		err = r.finish(typeName(v))
+	}
+	bytesReaders.Put(r)
+	return
+}
+
+func isEOF(err error) bool {
+	return errors.Cause(err) == io.EOF
+}
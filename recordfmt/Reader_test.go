@@ -0,0 +1,387 @@
+package recordfmt_test
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+	"github.com/maxbet1507/tlsaux/testcert"
+	"github.com/pkg/errors"
+)
+
+func TestHandshakeBodyError_TooLarge(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// body length
+		0xff, 0xff, 0xff,
+		// body
+		0x10, 0x11,
+	})
+
+	var val recordfmt.HandshakeBody
+	err := val.Decode(buf)
+	if errors.Cause(err) != recordfmt.ErrTooLarge {
+		t.Fatal(err)
+	}
+	if v, ok := err.(*recordfmt.DecodeError); !ok || v.Type != "HandshakeBody" || v.Offset != 0 {
+		t.Fatal(err)
+	}
+}
+
+func TestHandshakeBodyError_Truncated(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// body length
+		0x0f, 0xff, 0xff,
+		// body
+		0x10, 0x11,
+	})
+
+	options := &recordfmt.DecodeOptions{MaxHandshakeLength: 0xffffff}
+
+	var val recordfmt.HandshakeBody
+	err := val.Decode(recordfmt.NewReader(buf, options))
+	if errors.Cause(err) != io.ErrUnexpectedEOF {
+		t.Fatal(err)
+	}
+	if v, ok := err.(*recordfmt.DecodeError); !ok || v.Offset != 3 {
+		t.Fatal(err)
+	}
+}
+
+func TestCipherSuitesError_Truncated(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// cipher suites length
+		0x00, 0x03,
+		// cipher suites
+		0x10, 0x11, 0x12,
+	})
+
+	val := recordfmt.CipherSuites{0x2021}
+	err := val.Decode(buf)
+	if errors.Cause(err) != io.ErrUnexpectedEOF {
+		t.Fatal(err)
+	}
+	if v, ok := err.(*recordfmt.DecodeError); !ok || v.Type != "CipherSuite" || v.Offset != 4 {
+		t.Fatal(err)
+	}
+	if len(val) != 1 || val[0] != 0x2021 {
+		t.Fatal(val)
+	}
+}
+
+func TestFragmentError_TooLarge(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{
+		// fragment length
+		0x00, 0x10,
+	})
+
+	options := &recordfmt.DecodeOptions{MaxFragmentLength: 0x0f}
+
+	var val recordfmt.Fragment
+	if err := val.Decode(recordfmt.NewReader(buf, options)); errors.Cause(err) != recordfmt.ErrTooLarge {
+		t.Fatal(err)
+	}
+}
+
+func TestUnmarshal_Strict(t *testing.T) {
+	data := []byte{
+		// level
+		0x02,
+		// description
+		0x28,
+
+		// debris
+		0x30,
+	}
+
+	var val recordfmt.Alert
+	if err := recordfmt.Unmarshal(data, &val, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	err := recordfmt.Unmarshal(data, &val, &recordfmt.DecodeOptions{Strict: true})
+	if errors.Cause(err) != recordfmt.ErrTrailingData {
+		t.Fatal(err)
+	}
+	if v, ok := err.(*recordfmt.DecodeError); !ok || v.Type != "Alert" || v.Offset != 2 {
+		t.Fatal(err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	data := []byte{
+		// type
+		0x16,
+		// version
+		0x03, 0x03,
+		// fragment
+		0x00, 0x02, 0x10, 0x11,
+
+		// next record
+		0x15,
+	}
+
+	var val recordfmt.TLSPlaintext
+	rest, err := recordfmt.Parse(data, &val, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val.Type != recordfmt.TypeHandshake || val.Version != 0x0303 || !bytes.Equal(val.Fragment, []byte{0x10, 0x11}) {
+		t.Fatal(val)
+	}
+	if !bytes.Equal(rest, []byte{0x15}) {
+		t.Fatal(rest)
+	}
+
+	// fragment is a view into data, not a copy.
+	data[5] = 0x20
+	if val.Fragment[0] != 0x20 {
+		t.Fatal(val.Fragment)
+	}
+}
+
+func TestParse_Vector(t *testing.T) {
+	data := []byte{
+		// cipher suites length
+		0x00, 0x04,
+		// cipher suites
+		0x10, 0x11, 0x12, 0x13,
+
+		// next vector
+		0x00, 0x02, 0x20, 0x21,
+	}
+
+	var val recordfmt.CipherSuites
+	rest, err := recordfmt.Parse(data, &val, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(val) != 2 || val[0] != 0x1011 || val[1] != 0x1213 {
+		t.Fatal(val)
+	}
+	if !bytes.Equal(rest, data[6:]) {
+		t.Fatal(rest)
+	}
+
+	// an element overrunning its vector must not borrow from what follows.
+	data[1] = 0x03
+	_, err = recordfmt.Parse(data, &val, nil)
+	if v, ok := err.(*recordfmt.DecodeError); !ok || v.Type != "CipherSuite" || v.Offset != 4 {
+		t.Fatal(err)
+	}
+}
+
+func TestParse_Truncated(t *testing.T) {
+	data := []byte{
+		// type
+		0x16,
+		// version
+		0x03, 0x03,
+		// fragment
+		0x00, 0x03, 0x10, 0x11,
+	}
+
+	var val recordfmt.TLSPlaintext
+	_, err := recordfmt.Parse(data, &val, nil)
+	if errors.Cause(err) != io.ErrUnexpectedEOF {
+		t.Fatal(err)
+	}
+	if v, ok := err.(*recordfmt.DecodeError); !ok || v.Type != "Fragment" || v.Offset != 5 {
+		t.Fatal(err)
+	}
+}
+
+func clientHelloRecord(b *testing.B) []byte {
+	clconn, svconn := net.Pipe()
+	defer svconn.Close()
+
+	go func() {
+		defer clconn.Close()
+		tls.Client(clconn, &tls.Config{ServerName: "example.com"}).Handshake()
+	}()
+
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(svconn, buf); err != nil {
+		b.Fatal(err)
+	}
+	buf = append(buf, make([]byte, int(buf[3])<<8|int(buf[4]))...)
+	if _, err := io.ReadFull(svconn, buf[5:]); err != nil {
+		b.Fatal(err)
+	}
+	return buf
+}
+
+// helloWalk holds the views across iterations, so walking allocates nothing.
+type helloWalk struct {
+	record    recordfmt.TLSPlaintext
+	handshake recordfmt.Handshake
+	hello     recordfmt.ClientHelloView
+	names     recordfmt.ServerNameListView
+	groups    recordfmt.SupportedGroupsView
+}
+
+func (s *helloWalk) walk(b *testing.B, data []byte) (n int) {
+	if _, err := recordfmt.Parse(data, &s.record, nil); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := recordfmt.Parse(s.record.Fragment, &s.handshake, nil); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := recordfmt.Parse(s.handshake.Body, &s.hello, nil); err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < s.hello.CipherSuites.Len(); i++ {
+		n += int(s.hello.CipherSuites.At(i))
+	}
+	for v, rest, ok := s.hello.Extensions.Next(); ok; v, rest, ok = rest.Next() {
+		var err error
+		switch v.ExtensionType {
+		case recordfmt.ExtensionServerName:
+			if err = recordfmt.Unmarshal(v.ExtensionData, &s.names, nil); err == nil {
+				for w, rest, ok := s.names.Next(); ok; w, rest, ok = rest.Next() {
+					n += len(w.HostName)
+				}
+			}
+		case recordfmt.ExtensionSupportedGroups:
+			if err = recordfmt.Unmarshal(v.ExtensionData, &s.groups, nil); err == nil {
+				for i := 0; i < s.groups.Len(); i++ {
+					n += int(s.groups.At(i))
+				}
+			}
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	return
+}
+
+func decodeClientHello(b *testing.B, data []byte) {
+	var record recordfmt.TLSPlaintext
+	if err := record.Decode(bytes.NewReader(data)); err != nil {
+		b.Fatal(err)
+	}
+	var handshake recordfmt.Handshake
+	if err := handshake.Decode(bytes.NewReader(record.Fragment)); err != nil {
+		b.Fatal(err)
+	}
+	var hello recordfmt.ClientHello
+	if err := hello.Decode(bytes.NewReader(handshake.Body)); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkParse_ClientHello(b *testing.B) {
+	data := clientHelloRecord(b)
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	var s helloWalk
+	for i := 0; i < b.N; i++ {
+		s.walk(b, data)
+	}
+}
+
+func BenchmarkDecode_ClientHello(b *testing.B) {
+	data := clientHelloRecord(b)
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		decodeClientHello(b, data)
+	}
+}
+
+type flightConn struct {
+	net.Conn
+	flight chan []byte
+}
+
+func (s *flightConn) Write(p []byte) (int, error) {
+	select {
+	case s.flight <- append([]byte(nil), p...):
+	default:
+	}
+	return s.Conn.Write(p)
+}
+
+func serverFlight(b *testing.B) []byte {
+	cert, pkey, _ := testcert.SelfSigned(2048, 10*time.Second)
+	pair, _ := tls.X509KeyPair(cert, pkey)
+
+	clconn, svconn := net.Pipe()
+	defer clconn.Close()
+	defer svconn.Close()
+
+	conn := &flightConn{Conn: svconn, flight: make(chan []byte, 1)}
+	go tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{pair}}).Handshake()
+	go tls.Client(clconn, &tls.Config{InsecureSkipVerify: true, MaxVersion: tls.VersionTLS12}).Handshake()
+
+	// ServerHello, Certificate, ServerKeyExchange and ServerHelloDone in one write.
+	return <-conn.flight
+}
+
+// flightWalk holds the views across iterations, so walking allocates nothing
+// but the Reader for the ServerKeyExchange.
+type flightWalk struct {
+	record      recordfmt.TLSPlaintext
+	handshake   recordfmt.Handshake
+	hello       recordfmt.ServerHelloView
+	certificate recordfmt.CertificateView
+	exchange    recordfmt.ServerKeyExchange
+}
+
+func (s *flightWalk) walk(b *testing.B, data []byte) (n int) {
+	for rest := data; len(rest) > 0; {
+		var err error
+		if rest, err = recordfmt.Parse(rest, &s.record, nil); err != nil {
+			b.Fatal(err)
+		}
+
+		for frag := s.record.Fragment; len(frag) > 0; {
+			if frag, err = recordfmt.Parse(frag, &s.handshake, nil); err != nil {
+				b.Fatal(err)
+			}
+
+			switch s.handshake.MsgType {
+			case recordfmt.TypeServerHello:
+				if err = recordfmt.Unmarshal(s.handshake.Body, &s.hello, nil); err == nil {
+					n += int(s.hello.CipherSuite) + s.hello.Extensions.Len()
+				}
+			case recordfmt.TypeCertificate:
+				if err = recordfmt.Unmarshal(s.handshake.Body, &s.certificate, nil); err == nil {
+					// the chain is walked entry by entry, the DER itself stays opaque.
+					for v, rest, ok := s.certificate.CertificateList.Next(); ok; v, rest, ok = rest.Next() {
+						n += len(v)
+					}
+				}
+			case recordfmt.TypeServerKeyExchange:
+				if err = s.exchange.DecodeECDHE(recordfmt.NewBytesReader(s.handshake.Body, nil), s.record.Version); err == nil {
+					n += len(s.exchange.Signature)
+				}
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	return
+}
+
+func BenchmarkParse_ServerFlight(b *testing.B) {
+	data := serverFlight(b)
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	var s flightWalk
+	for i := 0; i < b.N; i++ {
+		s.walk(b, data)
+	}
+}
@@ -0,0 +1,235 @@
+package recordfmt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// SSLv2 CLIENT-HELLO is only valid as the first record of a connection, see
+// RFC 5246 Appendix E.2. The decoders here see a single record and cannot
+// tell its position, the callers that follow a connection enforce it.
+
+// -
+const (
+	SSLv2MsgClientHello = 1
+)
+
+// IsSSLv2Record -
+func IsSSLv2Record(header []byte) bool {
+	// no TLS content type has the high bit set.
+	return len(header) >= 1 && header[0]&0x80 != 0
+}
+
+// RecordLength -
+func RecordLength(header []byte) (n int, ok bool) {
+	switch {
+	case IsSSLv2Record(header) && len(header) >= 2:
+		n, ok = 2+(int(header[0]&0x7f)<<8)+int(header[1]), true
+	case !IsSSLv2Record(header) && len(header) >= 5:
+		n, ok = 5+(int(header[3])<<8)+int(header[4]), true
+	}
+	return
+}
+
+// SSLv2CipherSpec -
+type SSLv2CipherSpec uint32
+
+// Decode -
+func (s *SSLv2CipherSpec) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *SSLv2CipherSpec) parse(d *Reader) (err error) {
+	var raw int
+	if raw, err = d.readUint24("SSLv2CipherSpec"); err == nil {
+		*s = SSLv2CipherSpec(raw)
+	}
+	return
+}
+
+// CipherSuite -
+func (s SSLv2CipherSpec) CipherSuite() (CipherSuite, bool) {
+	// TLS cipher suites are carried with a leading zero byte.
+	return CipherSuite(s), s>>16 == 0
+}
+
+// SSLv2CipherSpecsView -
+type SSLv2CipherSpecsView []byte
+
+// Len -
+func (s SSLv2CipherSpecsView) Len() int {
+	return len(s) / 3
+}
+
+// At -
+func (s SSLv2CipherSpecsView) At(i int) SSLv2CipherSpec {
+	return SSLv2CipherSpec(int(s[3*i])<<16 | int(s[3*i+1])<<8 | int(s[3*i+2]))
+}
+
+// SSLv2CipherSpecs -
+func (s SSLv2CipherSpecsView) SSLv2CipherSpecs() []SSLv2CipherSpec {
+	if s == nil {
+		return nil
+	}
+	v := make([]SSLv2CipherSpec, s.Len())
+	for i := range v {
+		v[i] = s.At(i)
+	}
+	return v
+}
+
+// SSLv2ClientHello -
+type SSLv2ClientHello struct {
+	Version     ProtocolVersion
+	CipherSpecs []SSLv2CipherSpec
+	SessionID   SessionID
+	Challenge   []byte
+}
+
+// Decode -
+func (s *SSLv2ClientHello) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *SSLv2ClientHello) parse(d *Reader) (err error) {
+	var v SSLv2ClientHelloView
+	if err = v.parse(d); err == nil {
+		*s = SSLv2ClientHello{
+			Version:     v.Version,
+			CipherSpecs: v.CipherSpecs.SSLv2CipherSpecs(),
+			SessionID:   v.SessionID,
+			Challenge:   v.Challenge,
+		}
+	}
+	return
+}
+
+// SSLv2ClientHelloView -
+type SSLv2ClientHelloView struct {
+	Version     ProtocolVersion
+	CipherSpecs SSLv2CipherSpecsView
+	SessionID   SessionID
+	Challenge   []byte
+}
+
+// Decode -
+func (s *SSLv2ClientHelloView) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *SSLv2ClientHelloView) parse(d *Reader) (err error) {
+	var v SSLv2ClientHelloView
+
+	offset := d.offset
+
+	var msgType uint8
+	var specs, session, challenge uint16
+	if msgType, err = d.readUint8("SSLv2ClientHello"); err == nil && msgType != SSLv2MsgClientHello {
+		err = d.fail("SSLv2ClientHello", offset, ErrMalformed, fmt.Sprintf("unexpected message type %d", msgType))
+	}
+	if err == nil {
+		err = v.Version.parse(d)
+	}
+	if err == nil {
+		specs, err = d.readUint16("SSLv2CipherSpecs")
+	}
+	if err == nil {
+		session, err = d.readUint16("SessionID")
+	}
+	if err == nil {
+		challenge, err = d.readUint16("Challenge")
+	}
+	if err == nil && (specs%3 != 0 || (session != 0 && session != 16) || challenge < 16 || challenge > 32) {
+		err = d.fail("SSLv2ClientHello", offset, ErrMalformed, "invalid lengths")
+	}
+
+	if err == nil {
+		v.CipherSpecs, err = d.readOpaque("SSLv2CipherSpecs", int(specs))
+	}
+	if err == nil {
+		v.SessionID, err = d.readOpaque("SessionID", int(session))
+	}
+	if err == nil {
+		v.Challenge, err = d.readOpaque("Challenge", int(challenge))
+	}
+
+	if err == nil {
+		*s = v
+	}
+	return
+}
+
+// ToClientHello -
+func (s *SSLv2ClientHello) ToClientHello() *ClientHello {
+	v := &ClientHello{
+		ClientVersion:      s.Version,
+		Random:             make(Random, 32),
+		SessionID:          s.SessionID,
+		CompressionMethods: CompressionMethods{0},
+	}
+
+	// the challenge is right-justified into the random, padded with zeros.
+	challenge := s.Challenge
+	if len(challenge) > 32 {
+		challenge = challenge[len(challenge)-32:]
+	}
+	copy(v.Random[32-len(challenge):], challenge)
+
+	for _, spec := range s.CipherSpecs {
+		if suite, ok := spec.CipherSuite(); ok {
+			v.CipherSuites = append(v.CipherSuites, suite)
+		}
+	}
+	return v
+}
+
+func (s *ClientHello) encode() []byte {
+	var body bytes.Buffer
+
+	// extensions are omitted, SSLv2 hellos never carry them.
+	body.Write([]byte{byte(s.ClientVersion >> 8), byte(s.ClientVersion)})
+	body.Write(s.Random)
+	body.WriteByte(byte(len(s.SessionID)))
+	body.Write(s.SessionID)
+	suites := 2 * len(s.CipherSuites)
+	body.Write([]byte{byte(suites >> 8), byte(suites)})
+	for _, v := range s.CipherSuites {
+		body.Write([]byte{byte(v >> 8), byte(v)})
+	}
+	body.WriteByte(byte(len(s.CompressionMethods)))
+	for _, v := range s.CompressionMethods {
+		body.WriteByte(byte(v))
+	}
+
+	n := body.Len()
+	return append([]byte{byte(TypeClientHello), byte(n >> 16), byte(n >> 8), byte(n)}, body.Bytes()...)
+}
+
+func (s *TLSPlaintext) decodeSSLv2(d *Reader, first uint8) (err error) {
+	// the first byte of the header is already read as the content type.
+	offset := d.offset - 1
+
+	var second uint8
+	if second, err = d.readUint8("SSLv2Record"); err == nil {
+		length := (int(first&0x7f) << 8) + int(second)
+
+		var raw []byte
+		if limit := d.options.MaxFragmentLength; length > limit {
+			err = d.fail("SSLv2Record", offset, ErrTooLarge, fmt.Sprintf("length %d exceeds limit %d", length, limit))
+		} else if raw, err = d.readOpaque("SSLv2Record", length); err == nil {
+			var hello SSLv2ClientHello
+			r, limit := d.enter(raw)
+			err = hello.parse(r)
+			d.leave(r, limit)
+			if err == nil {
+				*s = TLSPlaintext{
+					Type:     TypeHandshake,
+					Version:  hello.Version,
+					Fragment: hello.ToClientHello().encode(),
+				}
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,150 @@
+package recordfmt_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+	"github.com/pkg/errors"
+)
+
+func sslv2ClientHello() []byte {
+	return []byte{
+		// record header
+		0x80, 0x22,
+		// msg type
+		0x01,
+		// version
+		0x03, 0x01,
+		// cipher specs length
+		0x00, 0x09,
+		// session id length
+		0x00, 0x00,
+		// challenge length
+		0x00, 0x10,
+		// cipher specs
+		0x07, 0x00, 0xc0,
+		0x00, 0x00, 0x2f,
+		0x00, 0x00, 0x0a,
+		// challenge
+		0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17,
+		0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+	}
+}
+
+func TestRecordLength(t *testing.T) {
+	if n, ok := recordfmt.RecordLength(sslv2ClientHello()); !ok || n != 0x24 {
+		t.Fatal(n, ok)
+	}
+	if n, ok := recordfmt.RecordLength([]byte{0x16, 0x03, 0x01, 0x00, 0x10}); !ok || n != 0x15 {
+		t.Fatal(n, ok)
+	}
+	if _, ok := recordfmt.RecordLength([]byte{0x16, 0x03, 0x01}); ok {
+		t.Fatal(ok)
+	}
+}
+
+func TestTLSPlaintext_SSLv2(t *testing.T) {
+	var record recordfmt.TLSPlaintext
+	if err := record.Decode(bytes.NewBuffer(sslv2ClientHello())); err != nil {
+		t.Fatal(err)
+	}
+	if record.Type != recordfmt.TypeHandshake || record.Version != 0x0301 {
+		t.Fatal(record)
+	}
+
+	var handshake recordfmt.Handshake
+	if err := recordfmt.Unmarshal(record.Fragment, &handshake, &recordfmt.DecodeOptions{Strict: true}); err != nil {
+		t.Fatal(err)
+	}
+	if handshake.MsgType != recordfmt.TypeClientHello {
+		t.Fatal(handshake)
+	}
+
+	var val recordfmt.ClientHello
+	if err := recordfmt.Unmarshal(handshake.Body, &val, &recordfmt.DecodeOptions{Strict: true}); err != nil {
+		t.Fatal(err)
+	}
+	if val.ClientVersion != 0x0301 {
+		t.Fatal(val)
+	}
+	random := append(make([]byte, 16), sslv2ClientHello()[20:]...)
+	if !bytes.Equal(val.Random, random) {
+		t.Fatal(val.Random)
+	}
+	if len(val.CipherSuites) != 2 || val.CipherSuites[0] != 0x002f || val.CipherSuites[1] != 0x000a {
+		t.Fatal(val.CipherSuites)
+	}
+	if len(val.CompressionMethods) != 1 || val.CompressionMethods[0] != 0 {
+		t.Fatal(val.CompressionMethods)
+	}
+}
+
+func TestSSLv2ClientHelloView(t *testing.T) {
+	data := sslv2ClientHello()[2:]
+
+	var val recordfmt.SSLv2ClientHelloView
+	if err := recordfmt.Unmarshal(data, &val, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if val.Version != 0x0301 || val.CipherSpecs.Len() != 3 {
+		t.Fatal(val)
+	}
+	if val.CipherSpecs.At(0) != 0x0700c0 || val.CipherSpecs.At(1) != 0x00002f || val.CipherSpecs.At(2) != 0x00000a {
+		t.Fatal(val)
+	}
+	if len(val.SessionID) != 0 || len(val.Challenge) != 16 || val.Challenge[0] != 0x10 {
+		t.Fatal(val)
+	}
+}
+
+func TestSSLv2ClientHelloError_Malformed(t *testing.T) {
+	data := sslv2ClientHello()[2:]
+	data[8] = 0x08 // challenge too short
+
+	var val recordfmt.SSLv2ClientHello
+	err := recordfmt.Unmarshal(data, &val, nil)
+	if errors.Cause(err) != recordfmt.ErrMalformed {
+		t.Fatal(err)
+	}
+	if v, ok := err.(*recordfmt.DecodeError); !ok || v.Type != "SSLv2ClientHello" || v.Offset != 0 {
+		t.Fatal(err)
+	}
+}
+
+func TestSSLv2ClientHelloError_SessionID(t *testing.T) {
+	data := sslv2ClientHello()[2:]
+	data[6] = 0x08 // neither empty nor 16 bytes
+
+	var val recordfmt.SSLv2ClientHello
+	err := recordfmt.Unmarshal(data, &val, nil)
+	if errors.Cause(err) != recordfmt.ErrMalformed {
+		t.Fatal(err)
+	}
+}
+
+func TestTLSPlaintextError_SSLv2TooLarge(t *testing.T) {
+	options := &recordfmt.DecodeOptions{MaxFragmentLength: 0x21}
+
+	var record recordfmt.TLSPlaintext
+	_, err := recordfmt.Parse(sslv2ClientHello(), &record, options)
+	if errors.Cause(err) != recordfmt.ErrTooLarge {
+		t.Fatal(err)
+	}
+	if v, ok := err.(*recordfmt.DecodeError); !ok || v.Type != "SSLv2Record" || v.Offset != 0 {
+		t.Fatal(err)
+	}
+}
+
+func TestSSLv2ClientHello_ToClientHelloLongChallenge(t *testing.T) {
+	val := recordfmt.SSLv2ClientHello{Version: 0x0301, Challenge: make([]byte, 40)}
+	for i := range val.Challenge {
+		val.Challenge[i] = byte(i)
+	}
+
+	// only the last 32 bytes fit the random.
+	if v := val.ToClientHello(); !bytes.Equal(v.Random, val.Challenge[8:]) {
+		t.Fatal(v.Random)
+	}
+}
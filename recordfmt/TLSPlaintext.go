@@ -1,7 +1,6 @@
 package recordfmt
 
 import (
-	"encoding/binary"
 	"io"
 )
 
@@ -17,9 +16,13 @@ const (
 )
 
 // Decode -
-func (s *ContentType) Decode(r io.Reader) (err error) {
+func (s *ContentType) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *ContentType) parse(d *Reader) (err error) {
 	var raw uint8
-	if err = binary.Read(r, binary.BigEndian, &raw); err == nil {
+	if raw, err = d.readUint8("ContentType"); err == nil {
 		*s = ContentType(raw)
 	}
 	return
@@ -29,9 +32,13 @@ func (s *ContentType) Decode(r io.Reader) (err error) {
 type ProtocolVersion int
 
 // Decode -
-func (s *ProtocolVersion) Decode(r io.Reader) (err error) {
+func (s *ProtocolVersion) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *ProtocolVersion) parse(d *Reader) (err error) {
 	var raw uint16
-	if err = binary.Read(r, binary.BigEndian, &raw); err == nil {
+	if raw, err = d.readUint16("ProtocolVersion"); err == nil {
 		*s = ProtocolVersion(raw)
 	}
 	return
@@ -41,13 +48,14 @@ func (s *ProtocolVersion) Decode(r io.Reader) (err error) {
 type Fragment []byte
 
 // Decode -
-func (s *Fragment) Decode(r io.Reader) (err error) {
-	var aux uint16
-	if err = binary.Read(r, binary.BigEndian, &aux); err == nil {
-		raw := make([]byte, aux)
-		if err = binary.Read(r, binary.BigEndian, &raw); err == nil {
-			*s = raw
-		}
+func (s *Fragment) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *Fragment) parse(d *Reader) (err error) {
+	var raw []byte
+	if raw, err = d.readVector("Fragment", 2, d.options.MaxFragmentLength); err == nil {
+		*s = raw
 	}
 	return
 }
@@ -60,16 +68,22 @@ type TLSPlaintext struct {
 }
 
 // Decode -
-func (s *TLSPlaintext) Decode(r io.Reader) (err error) {
+func (s *TLSPlaintext) Decode(r io.Reader) error {
+	return s.parse(newReader(r))
+}
+
+func (s *TLSPlaintext) parse(d *Reader) (err error) {
 	var v TLSPlaintext
 
-	fn := []func(io.Reader) error{
-		v.Type.Decode,
-		v.Version.Decode,
-		v.Fragment.Decode,
+	if err = v.Type.parse(d); err == nil && v.Type&0x80 != 0 {
+		// SSLv2 CLIENT-HELLO, presented as the equivalent TLS record.
+		return s.decodeSSLv2(d, uint8(v.Type))
 	}
-	for i := 0; i < len(fn) && err == nil; i++ {
-		err = fn[i](r)
+	if err == nil {
+		err = v.Version.parse(d)
+	}
+	if err == nil {
+		err = v.Fragment.parse(d)
 	}
 
 	if err == nil {
@@ -0,0 +1,33 @@
+package verify
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// -
+var (
+	ErrNoCertificate = fmt.Errorf("No Certificate")
+)
+
+// Chain -
+func Chain(certs []*x509.Certificate, roots *x509.CertPool, serverName string, at time.Time) (chains [][]*x509.Certificate, err error) {
+	if err = assert(len(certs) > 0, ErrNoCertificate); err == nil {
+		opts := x509.VerifyOptions{
+			Roots:         roots,
+			DNSName:       serverName,
+			CurrentTime:   at,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, v := range certs[1:] {
+			opts.Intermediates.AddCert(v)
+		}
+
+		chains, err = certs[0].Verify(opts)
+		err = errors.Wrap(err, "Chain")
+	}
+	return
+}
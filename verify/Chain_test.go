@@ -0,0 +1,68 @@
+package verify_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/maxbet1507/tlsaux/testcert"
+	"github.com/maxbet1507/tlsaux/verify"
+	"github.com/pkg/errors"
+)
+
+func selfSigned(t *testing.T) *x509.Certificate {
+	cert, _, _ := testcert.SelfSigned(1024, 10*time.Second)
+	block, _ := pem.Decode(cert)
+
+	ret, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ret
+}
+
+func TestChain(t *testing.T) {
+	cert := selfSigned(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	chains, err := verify.Chain([]*x509.Certificate{cert}, roots, "localhost", time.Now())
+	if err != nil || len(chains) != 1 {
+		t.Fatal(chains, err)
+	}
+}
+
+func TestChainError_ServerName(t *testing.T) {
+	cert := selfSigned(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	if _, err := verify.Chain([]*x509.Certificate{cert}, roots, "example.com", time.Now()); err == nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChainError_Expired(t *testing.T) {
+	cert := selfSigned(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	if _, err := verify.Chain([]*x509.Certificate{cert}, roots, "localhost", time.Now().Add(time.Hour)); err == nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChainError_UnknownAuthority(t *testing.T) {
+	cert := selfSigned(t)
+
+	if _, err := verify.Chain([]*x509.Certificate{cert}, x509.NewCertPool(), "localhost", time.Now()); err == nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChainError_NoCertificate(t *testing.T) {
+	if _, err := verify.Chain(nil, x509.NewCertPool(), "localhost", time.Now()); errors.Cause(err) != verify.ErrNoCertificate {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,166 @@
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	_ "crypto/md5" // register hash
+	"crypto/rsa"
+	_ "crypto/sha1"   // register hash
+	_ "crypto/sha256" // register hash
+	_ "crypto/sha512" // register hash
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+	"github.com/pkg/errors"
+)
+
+type signatureType int
+
+const (
+	signaturePKCS1v15 = signatureType(iota)
+	signatureRSAPSS
+	signatureECDSA
+	signatureEd25519
+)
+
+type signatureScheme struct {
+	Type signatureType
+	Hash crypto.Hash
+}
+
+// -
+var (
+	ErrUnsupportedScheme = fmt.Errorf("Unsupported Scheme")
+	ErrKeyMismatch       = fmt.Errorf("Key Mismatch")
+	ErrInvalidSignature  = fmt.Errorf("Invalid Signature")
+
+	signatureSchemes = map[recordfmt.SignatureScheme]signatureScheme{
+		0x0201: {signaturePKCS1v15, crypto.SHA1},   //rsa_pkcs1_sha1
+		0x0401: {signaturePKCS1v15, crypto.SHA256}, //rsa_pkcs1_sha256
+		0x0501: {signaturePKCS1v15, crypto.SHA384}, //rsa_pkcs1_sha384
+		0x0601: {signaturePKCS1v15, crypto.SHA512}, //rsa_pkcs1_sha512
+		0x0203: {signatureECDSA, crypto.SHA1},      //ecdsa_sha1
+		0x0403: {signatureECDSA, crypto.SHA256},    //ecdsa_secp256r1_sha256
+		0x0503: {signatureECDSA, crypto.SHA384},    //ecdsa_secp384r1_sha384
+		0x0603: {signatureECDSA, crypto.SHA512},    //ecdsa_secp521r1_sha512
+		0x0804: {signatureRSAPSS, crypto.SHA256},   //rsa_pss_rsae_sha256
+		0x0805: {signatureRSAPSS, crypto.SHA384},   //rsa_pss_rsae_sha384
+		0x0806: {signatureRSAPSS, crypto.SHA512},   //rsa_pss_rsae_sha512
+		0x0807: {signatureEd25519, 0},              //ed25519
+		0x0809: {signatureRSAPSS, crypto.SHA256},   //rsa_pss_pss_sha256
+		0x080a: {signatureRSAPSS, crypto.SHA384},   //rsa_pss_pss_sha384
+		0x080b: {signatureRSAPSS, crypto.SHA512},   //rsa_pss_pss_sha512
+	}
+)
+
+func digest(h crypto.Hash, signed []byte) []byte {
+	switch h {
+	case 0:
+		return signed
+	case crypto.MD5SHA1:
+		return append(digest(crypto.MD5, signed), digest(crypto.SHA1, signed)...)
+	}
+	w := h.New()
+	w.Write(signed) // always success
+	return w.Sum(nil)
+}
+
+func verifySignature(pub crypto.PublicKey, scheme signatureScheme, signed, sig []byte) (err error) {
+	switch scheme.Type {
+	case signaturePKCS1v15, signatureRSAPSS:
+		key, ok := pub.(*rsa.PublicKey)
+		if err = assert(ok, ErrKeyMismatch); err == nil {
+			if scheme.Type == signatureRSAPSS {
+				opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+				err = rsa.VerifyPSS(key, scheme.Hash, digest(scheme.Hash, signed), sig, opts)
+			} else {
+				err = rsa.VerifyPKCS1v15(key, scheme.Hash, digest(scheme.Hash, signed), sig)
+			}
+			if err != nil {
+				err = errors.Wrap(ErrInvalidSignature, err.Error())
+			}
+		}
+
+	case signatureECDSA:
+		key, ok := pub.(*ecdsa.PublicKey)
+		if err = assert(ok, ErrKeyMismatch); err == nil {
+			err = assert(ecdsa.VerifyASN1(key, digest(scheme.Hash, signed), sig), ErrInvalidSignature)
+		}
+
+	case signatureEd25519:
+		key, ok := pub.(ed25519.PublicKey)
+		if err = assert(ok, ErrKeyMismatch); err == nil {
+			err = assert(ed25519.Verify(key, signed, sig), ErrInvalidSignature)
+		}
+	}
+	return
+}
+
+func verifyScheme(pub crypto.PublicKey, algorithm recordfmt.SignatureScheme, signed, sig []byte) (err error) {
+	scheme, ok := signatureSchemes[algorithm]
+	if err = assert(ok, ErrUnsupportedScheme); err == nil {
+		err = verifySignature(pub, scheme, signed, sig)
+	}
+	return
+}
+
+func verifyLegacy(pub crypto.PublicKey, signed, sig []byte) (err error) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		err = verifySignature(pub, signatureScheme{signaturePKCS1v15, crypto.MD5SHA1}, signed, sig)
+	case *ecdsa.PublicKey:
+		err = verifySignature(pub, signatureScheme{signatureECDSA, crypto.SHA1}, signed, sig)
+	default:
+		err = ErrUnsupportedScheme
+	}
+	return
+}
+
+// ServerKeyExchange -
+func ServerKeyExchange(leaf *x509.Certificate, version int, clientRandom, serverRandom []byte, ske *recordfmt.ServerKeyExchange) (err error) {
+	signed := bytes.Join([][]byte{clientRandom, serverRandom, ske.Params}, nil)
+
+	switch {
+	case leaf == nil:
+		err = ErrNoCertificate
+	case version >= tls.VersionTLS12:
+		err = verifyScheme(leaf.PublicKey, ske.Algorithm, signed, ske.Signature)
+	default:
+		err = verifyLegacy(leaf.PublicKey, signed, ske.Signature)
+	}
+	return errors.Wrap(err, "ServerKeyExchange")
+}
+
+// CertificateVerify12 -
+func CertificateVerify12(leaf *x509.Certificate, cv *recordfmt.CertificateVerify, handshakeMessages []byte) (err error) {
+	if err = assert(leaf != nil, ErrNoCertificate); err == nil {
+		err = verifyScheme(leaf.PublicKey, cv.Algorithm, handshakeMessages, cv.Signature)
+	}
+	return errors.Wrap(err, "CertificateVerify")
+}
+
+// CertificateVerify13 -
+func CertificateVerify13(leaf *x509.Certificate, cv *recordfmt.CertificateVerify, transcriptHash []byte, server bool) (err error) {
+	context := "TLS 1.3, client CertificateVerify"
+	if server {
+		context = "TLS 1.3, server CertificateVerify"
+	}
+	signed := bytes.Join([][]byte{
+		bytes.Repeat([]byte{0x20}, 64),
+		[]byte(context),
+		{0x00},
+		transcriptHash,
+	}, nil)
+
+	scheme, ok := signatureSchemes[cv.Algorithm]
+	if err = assert(leaf != nil, ErrNoCertificate); err == nil {
+		if err = assert(ok && scheme.Type != signaturePKCS1v15, ErrUnsupportedScheme); err == nil {
+			err = verifySignature(leaf.PublicKey, scheme, signed, cv.Signature)
+		}
+	}
+	return errors.Wrap(err, "CertificateVerify")
+}
@@ -0,0 +1,147 @@
+package verify_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"github.com/maxbet1507/tlsaux/recordfmt"
+	"github.com/maxbet1507/tlsaux/verify"
+	"github.com/pkg/errors"
+)
+
+func TestServerKeyExchange_RSAPSS(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 1024)
+	leaf := &x509.Certificate{PublicKey: &key.PublicKey}
+
+	crand, srand := bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x02}, 32)
+	ske := &recordfmt.ServerKeyExchange{
+		Params:    []byte{0x03, 0x00, 0x1d, 0x01, 0x10},
+		Algorithm: 0x0804,
+	}
+
+	digest := sha256.Sum256(bytes.Join([][]byte{crand, srand, ske.Params}, nil))
+	ske.Signature, _ = rsa.SignPSS(rand.Reader, key, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+
+	if err := verify.ServerKeyExchange(leaf, tls.VersionTLS12, crand, srand, ske); err != nil {
+		t.Fatal(err)
+	}
+
+	ske.Params[4] ^= 0xff
+	if err := verify.ServerKeyExchange(leaf, tls.VersionTLS12, crand, srand, ske); errors.Cause(err) != verify.ErrInvalidSignature {
+		t.Fatal(err)
+	}
+}
+
+func TestServerKeyExchange_Legacy(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 1024)
+	leaf := &x509.Certificate{PublicKey: &key.PublicKey}
+
+	crand, srand := bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x02}, 32)
+	ske := &recordfmt.ServerKeyExchange{
+		Params: []byte{0x03, 0x00, 0x17, 0x01, 0x10},
+	}
+
+	signed := bytes.Join([][]byte{crand, srand, ske.Params}, nil)
+	h1, h2 := md5.Sum(signed), sha1.Sum(signed)
+	ske.Signature, _ = rsa.SignPKCS1v15(rand.Reader, key, crypto.MD5SHA1, append(h1[:], h2[:]...))
+
+	if err := verify.ServerKeyExchange(leaf, tls.VersionTLS10, crand, srand, ske); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerKeyExchangeError_KeyMismatch(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leaf := &x509.Certificate{PublicKey: &key.PublicKey}
+
+	ske := &recordfmt.ServerKeyExchange{Algorithm: 0x0401}
+	if err := verify.ServerKeyExchange(leaf, tls.VersionTLS12, nil, nil, ske); errors.Cause(err) != verify.ErrKeyMismatch {
+		t.Fatal(err)
+	}
+
+	ske.Algorithm = 0xfefe
+	if err := verify.ServerKeyExchange(leaf, tls.VersionTLS12, nil, nil, ske); errors.Cause(err) != verify.ErrUnsupportedScheme {
+		t.Fatal(err)
+	}
+}
+
+func TestCertificateVerify12_ECDSA(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leaf := &x509.Certificate{PublicKey: &key.PublicKey}
+
+	messages := []byte("handshake messages")
+	digest := sha256.Sum256(messages)
+
+	cv := &recordfmt.CertificateVerify{Algorithm: 0x0403}
+	cv.Signature, _ = ecdsa.SignASN1(rand.Reader, key, digest[:])
+
+	if err := verify.CertificateVerify12(leaf, cv, messages); err != nil {
+		t.Fatal(err)
+	}
+	if err := verify.CertificateVerify12(leaf, cv, messages[1:]); errors.Cause(err) != verify.ErrInvalidSignature {
+		t.Fatal(err)
+	}
+}
+
+func TestCertificateVerify13_Ed25519(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(rand.Reader)
+	leaf := &x509.Certificate{PublicKey: pub}
+
+	transcript := sha256.Sum256([]byte("transcript"))
+	signed := bytes.Join([][]byte{
+		bytes.Repeat([]byte{0x20}, 64),
+		[]byte("TLS 1.3, server CertificateVerify"),
+		{0x00},
+		transcript[:],
+	}, nil)
+
+	cv := &recordfmt.CertificateVerify{
+		Algorithm: 0x0807,
+		Signature: ed25519.Sign(key, signed),
+	}
+
+	if err := verify.CertificateVerify13(leaf, cv, transcript[:], true); err != nil {
+		t.Fatal(err)
+	}
+	if err := verify.CertificateVerify13(leaf, cv, transcript[:], false); errors.Cause(err) != verify.ErrInvalidSignature {
+		t.Fatal(err)
+	}
+}
+
+func TestCertificateVerify13Error_PKCS1v15(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 1024)
+	leaf := &x509.Certificate{PublicKey: &key.PublicKey}
+
+	cv := &recordfmt.CertificateVerify{Algorithm: 0x0401}
+	if err := verify.CertificateVerify13(leaf, cv, nil, true); errors.Cause(err) != verify.ErrUnsupportedScheme {
+		t.Fatal(err)
+	}
+}
+
+func TestSignatureError_NoCertificate(t *testing.T) {
+	ske := &recordfmt.ServerKeyExchange{Algorithm: 0x0401}
+	for _, version := range []int{tls.VersionTLS10, tls.VersionTLS12} {
+		if err := verify.ServerKeyExchange(nil, version, nil, nil, ske); errors.Cause(err) != verify.ErrNoCertificate {
+			t.Fatal(err)
+		}
+	}
+
+	cv := &recordfmt.CertificateVerify{Algorithm: 0x0804}
+	if err := verify.CertificateVerify12(nil, cv, nil); errors.Cause(err) != verify.ErrNoCertificate {
+		t.Fatal(err)
+	}
+	if err := verify.CertificateVerify13(nil, cv, nil, true); errors.Cause(err) != verify.ErrNoCertificate {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,8 @@
+package verify
+
+func assert(f bool, err error) error {
+	if f {
+		err = nil
+	}
+	return err
+}